@@ -0,0 +1,186 @@
+package tdmq
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Authenticator authenticate each request by params or headers, ex: HMAC signature, appId, bearer token
+type Authenticator interface {
+	Authenticate(ctx context.Context, r *AuthRequest) error
+}
+
+// AuthFunc adapter to use ordinary function as Authenticator
+type AuthFunc func(ctx context.Context, r *AuthRequest) error
+
+func (f AuthFunc) Authenticate(ctx context.Context, r *AuthRequest) error {
+	return f(ctx, r)
+}
+
+// NoAuth send request without authentication, ex: mTLS only gateway with client certificate of WithClientCert
+var NoAuth Authenticator = AuthFunc(func(context.Context, *AuthRequest) error { return nil })
+
+// AuthRequest request to authenticate, params are sent in url query of GET or form body of POST
+type AuthRequest struct {
+	Action string
+	Method string      // GET, POST
+	URL    *url.URL    // endpoint without params
+	Header http.Header // header of request, ex: Authorization
+	Time   time.Time   // request time compensated by clock skew, see Client.ClockSkew
+
+	e      *encoder
+	sorted bool
+	rand   Rand
+	rl     *requestLog
+}
+
+// Get param of request
+//  input: key string
+//  return: string
+func (r *AuthRequest) Get(key string) string {
+	for _, p := range r.e.params {
+		if p.key == key {
+			return p.value
+		}
+	}
+	return ``
+}
+
+// Set param of request, replace the existing one
+//  input: key string
+//  input: value string
+func (r *AuthRequest) Set(key, value string) {
+	if r.sorted {
+		r.e.insert(key, value)
+	} else {
+		r.e.set(key, value)
+	}
+}
+
+// Nonce random number for replay protection
+//  return: uint32
+func (r *AuthRequest) Nonce() uint32 {
+	return r.rand.Uint32()
+}
+
+// StringToSign plain text of sorted params for HmacSHA1/HmacSHA256 signature: METHOD host path ? k=v&k=v
+//  return: []byte valid until the next call
+func (r *AuthRequest) StringToSign() []byte {
+	r.sort()
+	return r.e.stringToSign(r.Method, r.URL)
+}
+
+// Payload url encoded params, the query of GET request or the body of POST request
+//  return: []byte valid until the next call
+func (r *AuthRequest) Payload() []byte {
+	r.sort()
+	return r.e.encode()
+}
+
+func (r *AuthRequest) sort() {
+	if !r.sorted {
+		r.e.sort()
+		r.sorted = true
+	}
+}
+
+// HMACAuth sign request with secret key, HmacSHA1/HmacSHA256 in params or TC3-HMAC-SHA256 in Authorization header
+type HMACAuth struct {
+	Credential  CredentialProvider // required, ErrNoCredential when nil
+	SignMethod  string             // HmacSHA1, HmacSHA256, TC3-HMAC-SHA256
+	SignService string             // service in credential scope of TC3-HMAC-SHA256, default: tdmq
+}
+
+func (a *HMACAuth) Authenticate(ctx context.Context, r *AuthRequest) error {
+	if a.Credential == nil {
+		return ErrNoCredential
+	}
+	cred, err := a.Credential.Credential(ctx)
+	if err != nil {
+		return fmt.Errorf("get credential: %w", err)
+	}
+	signHMAC(ctx, r, cred, a.SignMethod, a.SignService)
+	return nil
+}
+
+// AppIdAuth request without signature for privatization, need gateway server option enabled
+type AppIdAuth uint64
+
+func (a AppIdAuth) Authenticate(_ context.Context, r *AuthRequest) error {
+	r.Set(`appId`, strconv.FormatUint(uint64(a), 10))
+	return nil
+}
+
+// BearerAuth set bearer token from function into Authorization header, ex: JWT of gateway
+type BearerAuth func(ctx context.Context) (token string, err error)
+
+func (f BearerAuth) Authenticate(ctx context.Context, r *AuthRequest) error {
+	token, err := f(ctx)
+	if err != nil {
+		return fmt.Errorf("get bearer token: %w", err)
+	}
+	r.Header.Set(`Authorization`, `Bearer `+token)
+	return nil
+}
+
+// authenticate request by Auth, or appId/HMAC by fields of client
+//  input: ctx context.Context
+//  input: r *AuthRequest
+//  return: error
+func (c *Client) authenticate(ctx context.Context, r *AuthRequest) error {
+	switch {
+	case c.Auth != nil:
+		return c.Auth.Authenticate(ctx, r)
+	case c.AppId > 0 && c.Credential == nil && c.SecretId == `` && c.SecretKey == ``:
+		return AppIdAuth(c.AppId).Authenticate(ctx, r)
+	}
+	cred, err := c.credential(ctx)
+	if err != nil {
+		return fmt.Errorf("get credential: %w", err)
+	}
+	signHMAC(ctx, r, cred, c.SignMethod, c.SignService)
+	return nil
+}
+
+// authRequest build authenticated request params and headers, the params are sorted and encoded
+//  input: ctx context.Context
+//  input: values url.Values
+//  input: id uint64 clientRequestId
+//  input: method string
+//  input: base *url.URL
+//  input: rl *requestLog
+//  return: *AuthRequest
+//  return: error
+func (c *Client) authRequest(ctx context.Context, values url.Values, id uint64, method string, base *url.URL, rl *requestLog) (*AuthRequest, error) {
+	header := make(http.Header, 1+len(c.Header))
+	header.Set(`Content-Type`, `application/x-www-form-urlencoded`)
+	for k, v := range c.Header {
+		header.Set(k, v)
+	}
+	for k, v := range headerFrom(ctx) {
+		header.Set(k, v)
+	}
+	// copy values into encoder, keep values unchanged for retry
+	r := &AuthRequest{
+		Action: values.Get(`Action`),
+		Method: method,
+		URL:    base,
+		Header: header,
+		Time:   c.now(),
+		e:      newEncoder(values),
+		rand:   c.rand(),
+		rl:     rl,
+	}
+	r.e.set(`RequestClient`, currentVersion)
+	r.e.set(`clientRequestId`, strconv.FormatUint(id, 10))
+	if err := c.authenticate(ctx, r); err != nil {
+		r.e.free()
+		return nil, err
+	}
+	r.Payload()
+	return r, nil
+}
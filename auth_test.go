@@ -0,0 +1,19 @@
+package tdmq
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestHMACAuthNoCredential HMACAuth without credential fails instead of panic
+func TestHMACAuthNoCredential(t *testing.T) {
+	c, err := NewClient(`http://127.0.0.1:1`, ``, ``, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Auth = &HMACAuth{SignMethod: HmacSHA256}
+	if _, err = c.SendMessage(`queue`, `message`, 0); !errors.Is(err, ErrNoCredential) {
+		t.Fatalf("expect ErrNoCredential: %v", err)
+	}
+}
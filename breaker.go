@@ -0,0 +1,178 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("circuit open")
+
+const (
+	circuitClosed = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// CircuitBreaker stop sending request to degraded endpoint, fail fast with ErrCircuitOpen
+//  closed: requests are sent, consecutive failures open the circuit
+//  open: requests are rejected until OpenTimeout elapsed, then half-open
+//  half-open: limited trial requests are sent, success close the circuit and failure open it again
+//  failures are transport errors, 429/5xx responses and CMQ response codes in Codes,
+//  requests cancelled by the caller's context or failed before sending are neither failure nor success.
+type CircuitBreaker struct {
+	Failures    int           // consecutive failures to open circuit, default: 5
+	OpenTimeout time.Duration // duration of open state before trial requests, default: 30s
+	HalfOpenMax int           // max concurrent trial requests in half-open state, default: 1
+	PerAction   bool          // break circuit by endpoint and action, default: by endpoint only
+	Codes       []int         // CMQ response codes count as failure, default: CodeThrottled, CodeInternalError
+
+	mu       sync.Mutex
+	circuits map[string]*circuit
+}
+
+type circuit struct {
+	key      string
+	state    int
+	gen      uint64 // generation, increased on each state change
+	failures int
+	trials   int
+	openedAt time.Time
+}
+
+// admission of request allowed by circuit
+type admission struct {
+	cc    *circuit
+	gen   uint64 // generation of circuit when admitted, report of the older generation is stale
+	trial bool   // admitted as trial request in half-open state
+}
+
+// allow check whether request is allowed to send to endpoint
+//  input: u *url.URL endpoint
+//  input: action string
+//  return: admission
+//  return: error
+func (b *CircuitBreaker) allow(u *url.URL, action string) (admission, error) {
+	if b == nil {
+		return admission{}, nil
+	}
+	key := u.Host + u.Path
+	if b.PerAction {
+		key += `#` + action
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.circuits == nil {
+		b.circuits = map[string]*circuit{}
+	}
+	cc, ok := b.circuits[key]
+	if !ok {
+		cc = &circuit{key: key}
+		b.circuits[key] = cc
+	}
+	switch cc.state {
+	case circuitOpen:
+		timeout := b.OpenTimeout
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		if time.Since(cc.openedAt) < timeout {
+			return admission{}, fmt.Errorf("%w: %s", ErrCircuitOpen, key)
+		}
+		cc.state, cc.trials = circuitHalfOpen, 0
+		cc.gen++
+		fallthrough
+	case circuitHalfOpen:
+		max := b.HalfOpenMax
+		if max <= 0 {
+			max = 1
+		}
+		if cc.trials >= max {
+			return admission{}, fmt.Errorf("%w: %s", ErrCircuitOpen, key)
+		}
+		cc.trials++
+		return admission{cc: cc, gen: cc.gen, trial: true}, nil
+	}
+	return admission{cc: cc, gen: cc.gen}, nil
+}
+
+// report result of request allowed by circuit, stale report after state change is ignored
+//  input: ctx context.Context caller's context of request
+//  input: a admission
+//  input: msg *msgResponse
+//  input: err error
+func (b *CircuitBreaker) report(ctx context.Context, a admission, msg *msgResponse, err error) {
+	if b == nil || a.cc == nil {
+		return
+	}
+	failed := b.failed(msg, err)
+	ignored := msg == nil && cancelled(ctx, err)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cc := a.cc
+	if a.gen != cc.gen {
+		return
+	}
+	if a.trial {
+		cc.trials--
+	}
+	switch {
+	case ignored:
+	case !failed:
+		if cc.state != circuitClosed {
+			cc.gen++
+		}
+		cc.state, cc.failures = circuitClosed, 0
+	default:
+		cc.failures++
+		max := b.Failures
+		if max <= 0 {
+			max = 5
+		}
+		if cc.state == circuitHalfOpen || cc.failures >= max {
+			cc.state, cc.openedAt = circuitOpen, time.Now()
+			cc.gen++
+		}
+	}
+}
+
+// release admission of request not sent, it's neither failure nor success
+//  input: a admission
+func (b *CircuitBreaker) release(a admission) {
+	if b == nil || a.cc == nil || !a.trial {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if a.gen == a.cc.gen {
+		a.cc.trials--
+	}
+}
+
+// failed check whether the result is failure of endpoint
+//  input: msg *msgResponse
+//  input: err error
+//  return: bool
+func (b *CircuitBreaker) failed(msg *msgResponse, err error) bool {
+	if msg == nil {
+		var e *url.Error
+		return errors.As(err, &e)
+	}
+	if msg.Status >= 500 || msg.Status == http.StatusTooManyRequests {
+		return true
+	}
+	codes := b.Codes
+	if codes == nil {
+		codes = []int{CodeThrottled, CodeInternalError}
+	}
+	for _, code := range codes {
+		if msg.Code_ == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestBreakerIgnoreCancelled requests cancelled by caller don't open circuit of healthy server
+func TestBreakerIgnoreCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Breaker = &CircuitBreaker{Failures: 2}
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, err = c.ReceiveMessageContext(ctx, `queue`, 1)
+		cancel()
+		if errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("circuit opened by cancelled request %d", i)
+		}
+	}
+}
+
+// TestBreakerStaleReport success admitted before circuit opened doesn't close it
+func TestBreakerStaleReport(t *testing.T) {
+	b := &CircuitBreaker{Failures: 1, OpenTimeout: time.Hour}
+	u := &url.URL{Host: `gateway`}
+	ctx := context.Background()
+	stale, err := b.allow(u, actionSendMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := b.allow(u, actionSendMsg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.report(ctx, a, &msgResponse{Status: http.StatusInternalServerError}, nil)
+	b.report(ctx, stale, &msgResponse{Status: http.StatusOK}, nil)
+	if _, err = b.allow(u, actionSendMsg); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("stale success closed circuit: %v", err)
+	}
+}
+
+// TestBreakerUnsent requests failed before sending neither close half-open circuit nor hold its trial slot
+func TestBreakerUnsent(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Breaker = &CircuitBreaker{Failures: 1, OpenTimeout: time.Millisecond}
+	if _, err = c.QueryQueueRoute(`queue`); err == nil {
+		t.Fatal("expect error of status 500")
+	}
+	limits := DefaultLimits()
+	limits.MaxURLLength = 16
+	method := c.Method
+	c.Method, c.Limits = http.MethodGet, &limits
+	for i := 0; i < 3; i++ {
+		time.Sleep(2 * time.Millisecond)
+		if _, err = c.QueryQueueRoute(`queue`); !errors.Is(err, ErrInvalidParameter) {
+			t.Fatalf("url length %d: %v", i, err)
+		}
+	}
+	c.Method, c.Limits = method, nil
+	c.Auth = AuthFunc(func(context.Context, *AuthRequest) error { return errors.New("no token") })
+	if _, err = c.QueryQueueRoute(`queue`); err == nil || errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("auth failure: %v", err)
+	}
+	for _, cc := range c.Breaker.circuits {
+		if cc.state != circuitHalfOpen || cc.trials != 0 {
+			t.Errorf("circuit %s state %d trials %d, expect half-open without trials", cc.key, cc.state, cc.trials)
+		}
+	}
+}
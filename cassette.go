@@ -0,0 +1,230 @@
+package tdmq
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// RecordMode of Recorder
+type RecordMode int
+
+const (
+	ModeRecord RecordMode = iota // send request by Transport and record the exchange
+	ModeReplay                   // serve recorded exchanges without network
+)
+
+var (
+	// NormalizeKeys request params change on each request, replaced by placeholder in cassette
+	NormalizeKeys = []string{`Signature`, `Nonce`, `Timestamp`, `clientRequestId`}
+	// SecretKeys request params with secret, redacted in cassette
+	SecretKeys = []string{`SecretId`, `Token`}
+)
+
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+type (
+	// Cassette recorded exchanges
+	Cassette struct {
+		Interactions []Interaction `json:"interactions"`
+	}
+
+	// Interaction a recorded request and its response
+	Interaction struct {
+		Method string            `json:"method"`
+		URL    string            `json:"url"`    // url without query, only path is matched in replay
+		Params map[string]string `json:"params"` // normalized params from query or form body
+		Status int               `json:"status"`
+		Header map[string]string `json:"header,omitempty"`
+		Body   string            `json:"body"`
+	}
+)
+
+// Recorder http.RoundTripper record requests of client into cassette file, or replay them in tests
+//  ex: client.HttpClient.Transport = recorder
+type Recorder struct {
+	Mode      RecordMode
+	Path      string            // cassette file
+	Transport http.RoundTripper // transport to send request in record mode, default: http.DefaultTransport
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder create recorder, the cassette file is loaded in replay mode
+//  input: path string
+//  input: mode RecordMode
+//  return: *Recorder
+//  return: error
+func NewRecorder(path string, mode RecordMode) (*Recorder, error) {
+	r := &Recorder{Mode: mode, Path: path}
+	if mode != ModeReplay {
+		return r, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	if err = json.Unmarshal(data, &r.cassette); err != nil {
+		return nil, fmt.Errorf("decode cassette: %w", err)
+	}
+	r.used = make([]bool, len(r.cassette.Interactions))
+	return r, nil
+}
+
+// Save write recorded interactions into cassette file
+//  return: error
+func (r *Recorder) Save() error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(``, `  `)
+	r.mu.Lock()
+	err := enc.Encode(&r.cassette)
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode cassette: %w", err)
+	}
+	if err = os.WriteFile(r.Path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write cassette: %w", err)
+	}
+	return nil
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read request body: %w", err)
+		}
+	}
+	params, err := normalize(req, body)
+	if err != nil {
+		return nil, err
+	}
+	u := *req.URL
+	u.RawQuery = ``
+
+	if r.Mode == ModeReplay {
+		return r.replay(req, params)
+	}
+
+	out := req.Clone(req.Context())
+	if req.Body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	i := Interaction{
+		Method: req.Method,
+		URL:    u.String(),
+		Params: params,
+		Status: resp.StatusCode,
+		Body:   string(data),
+	}
+	for _, k := range append([]string{`Content-Type`}, ErrorHeaders...) {
+		if v := resp.Header.Get(k); v != `` {
+			if i.Header == nil {
+				i.Header = map[string]string{}
+			}
+			i.Header[k] = v
+		}
+	}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, i)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay serve the first unused interaction matched with method, url path and params of request
+//  input: req *http.Request
+//  input: params map[string]string
+//  return: *http.Response
+//  return: error
+func (r *Recorder) replay(req *http.Request, params map[string]string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for idx, i := range r.cassette.Interactions {
+		if r.used[idx] || i.Method != req.Method || !reflect.DeepEqual(i.Params, params) {
+			continue
+		}
+		if u, err := url.Parse(i.URL); err != nil || u.Path != req.URL.Path {
+			continue
+		}
+		r.used[idx] = true
+		header := http.Header{}
+		for k, v := range i.Header {
+			header.Set(k, v)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+			StatusCode:    i.Status,
+			Proto:         `HTTP/1.1`,
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(i.Body)),
+			ContentLength: int64(len(i.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s %s", ErrNoInteraction, req.Method, req.URL.Path, params[`Action`])
+}
+
+// normalize request params from query or form body
+//  input: req *http.Request
+//  input: body []byte
+//  return: map[string]string
+//  return: error
+func normalize(req *http.Request, body []byte) (map[string]string, error) {
+	values := req.URL.Query()
+	if len(body) > 0 {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, fmt.Errorf("parse request body: %w", err)
+		}
+		for k, v := range form {
+			values[k] = append(values[k], v...)
+		}
+	}
+	params := make(map[string]string, len(values))
+	for k, v := range values {
+		params[k] = strings.Join(v, ``)
+	}
+	for _, k := range NormalizeKeys {
+		if _, ok := params[k]; ok {
+			params[k] = `<` + k + `>`
+		}
+	}
+	for _, k := range SecretKeys {
+		if _, ok := params[k]; ok {
+			params[k] = `***`
+		}
+	}
+	return params, nil
+}
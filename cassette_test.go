@@ -0,0 +1,67 @@
+package tdmq
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestRecorderRoundTrip record, save and replay with normalized params, each interaction is served once
+func TestRecorderRoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+	path := filepath.Join(t.TempDir(), `cassette.json`)
+	recorder, err := NewRecorder(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := NewClient(srv.URL, `AKIDrecordsecretid`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token = `recordsessiontoken`
+	c.HttpClient.Transport = recorder
+	if _, err = c.SendMessage(`queue`, `message`, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{c.SecretId, c.Token} {
+		if strings.Contains(string(data), s) {
+			t.Errorf("%q in cassette:\n%s", s, data)
+		}
+	}
+	srv.Close()
+
+	replayer, err := NewRecorder(path, ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// different signature, nonce, timestamp and clientRequestId
+	c, err = NewClient(srv.URL, `AKIDreplaysecretid`, `another`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token, c.Clock = `replaysessiontoken`, fixedClock(time.Now().Add(time.Hour))
+	c.HttpClient.Transport = replayer
+	res, err := c.SendMessage(`queue`, `message`, 0)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if res.MsgId() != `m` {
+		t.Errorf("replayed msgId: %s", res.MsgId())
+	}
+	if _, err = c.SendMessage(`queue`, `message`, 0); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("replay used interaction: %v", err)
+	}
+	if _, err = c.SendMessage(`queue`, `another message`, 0); !errors.Is(err, ErrNoInteraction) {
+		t.Errorf("replay different params: %v", err)
+	}
+}
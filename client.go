@@ -1,39 +1,56 @@
 package tdmq
 
 import (
+	"context"
 	"crypto/tls"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"strconv"
-	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// MethodAuto choose request method for each request, GET for small reads, POST for sending messages or long url
+const MethodAuto = `AUTO`
+
 type Client struct {
-	id         uint64   // clientRequestId
-	Url        *url.URL // ex: http://gateway.tdmq.io
-	Method     string   // GET, POST
-	SignMethod string   // HmacSHA1, HmacSHA256
-	SecretId   string   // AKIDxxxxx
-	SecretKey  string
-	Token      string
-	AppId      uint64 // appId for privatization, need gateway server option enabled
-	Header     map[string]string
-
-	Debug      bool // weather print request message
-	HttpClient *http.Client
-}
+	id          uint64   // last clientRequestId, increased for each request
+	skew        int64    // clock skew in nanoseconds, see ClockSkew
+	Url         *url.URL // ex: http://gateway.tdmq.io
+	Method      string   // GET, POST, AUTO
+	SignMethod  string   // HmacSHA1, HmacSHA256, TC3-HMAC-SHA256
+	SecretId    string   // AKIDxxxxx
+	SecretKey   string
+	Token       string
+	SignService string             // service in credential scope of TC3-HMAC-SHA256, default: tdmq
+	Credential  CredentialProvider // provide rotatable credential, override SecretId/SecretKey/Token when not nil
+	AppId       uint64             // appId for privatization, need gateway server option enabled
+	Auth        Authenticator      // custom authentication, override appId and HMAC signature by fields above when not nil
+	Header      map[string]string
+	Limits      *Limits         // limits of request parameters, nil means package level Max* variables, zero fields use them too
+	Timeout     time.Duration   // per request timeout, extended by polling wait seconds for each receive request
+	Retry       *RetryPolicy    // retry failed request, nil means no retry
+	CodeError   bool            // return *APIError with the result when response code is non-zero
+	NoSkew      bool            // do not compensate Timestamp of request by clock skew, see ClockSkew
+	Limiter     *RateLimiter    // client side rate limit, nil means unlimited
+	Breaker     *CircuitBreaker // stop sending request to degraded endpoint, nil means disabled
+	Inflight    *Registry       // track in-flight requests, nil means disabled
+	Endpoints   *Endpoints      // balance and fail over between multiple gateways, override Url when not nil
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
+	Interceptors []Interceptor // wrap each action in order, the first one is the outermost
+	Clock        Clock         // source of request Timestamp, nil means system clock
+	Rand         Rand          // source of Nonce and jitter, nil means a package level source
+	Tracer       Tracer        // start span for each action outside of interceptors, nil means no tracing
+	Metrics      Metrics       // observe each action outside of interceptors, ex: *Collector
+
+	Log        *LogConfig // log requests with credentials redacted
+	Debug      bool       // log request message to stdout in debug level when Log is nil
+	HttpClient *http.Client
 }
 
-// NewClient create TDMQ CMQ client
+// NewClient create TDMQ CMQ client, see NewClientWithOptions for more settings
 //  input: uri string request uri for TDMQ CMQ service
 //  input: secretId string user secret id from tencent cloud account
 //  input: secretKey string user secret key from tencent cloud account
@@ -45,12 +62,15 @@ func NewClient(uri, secretId, secretKey string, t time.Duration, keepalive ...bo
 	if len(keepalive) > 0 {
 		shortLive = !keepalive[0]
 	}
+	r := NewRand(0)
 	c = &Client{
-		id:         uint64(rand.Uint32()),
+		id:         uint64(r.Uint32()),
 		Method:     http.MethodPost,
 		SignMethod: HmacSHA1,
 		SecretId:   secretId,
 		SecretKey:  secretKey,
+		Timeout:    t,
+		Rand:       r,
 
 		HttpClient: &http.Client{
 			Transport: &http.Transport{
@@ -59,7 +79,6 @@ func NewClient(uri, secretId, secretKey string, t time.Duration, keepalive ...bo
 					InsecureSkipVerify: InsecureSkipVerify,
 				},
 			},
-			Timeout: t,
 		},
 	}
 
@@ -73,91 +92,254 @@ func NewClient(uri, secretId, secretKey string, t time.Duration, keepalive ...bo
 	return
 }
 
-func (c *Client) call(values url.Values) (msg *msgResponse, err error) {
-	values.Set(`RequestClient`, currentVersion)
-	if c.id > 0 {
-		values.Set(`clientRequestId`, strconv.FormatUint(c.id, 10))
+// timeout of single request, the long polling wait time only extend the budget of its own request
+//  input: values url.Values
+//  return: time.Duration
+func (c *Client) timeout(values url.Values) time.Duration {
+	t := c.Timeout
+	if v := values.Get(`pollingWaitSeconds`); v != `` {
+		wait, _ := strconv.Atoi(v)
+		if w := time.Duration(wait) * time.Second; w > t {
+			t = w + time.Second
+		}
+	}
+	return t
+}
+
+// method of request, GET for read in MethodAuto mode
+//  input: action string
+//  return: string GET, POST or unsupported c.Method
+func (c *Client) method(action string) string {
+	if c.Method != MethodAuto {
+		return c.Method
+	}
+	switch action {
+	case actionSendMsg, actionBatchSend, actionPubMsg, actionBatchPub:
+		return http.MethodPost
+	}
+	return http.MethodGet
+}
+
+// checkURL check length of GET request url
+//  input: base *url.URL
+//  input: query []byte
+//  return: error
+func (c *Client) checkURL(base *url.URL, query []byte) error {
+	max := c.limits().MaxURLLength
+	if n := len(base.String()) + 1 + len(query); max > 0 && n > max {
+		return fmt.Errorf("%w request url length(0<len<%d): %d", ErrInvalidParameter, max+1, n)
+	}
+	return nil
+}
+
+// call invoke action through interceptors
+//  input: ctx context.Context
+//  input: values url.Values
+//  return: Result *msgResponse or the one replaced by interceptor
+//  return: error
+func (c *Client) call(ctx context.Context, values url.Values) (Result, error) {
+	interceptors := c.Interceptors
+	if c.Metrics != nil {
+		interceptors = append([]Interceptor{observing(c.Metrics)}, interceptors...)
+	}
+	if c.Tracer != nil {
+		interceptors = append([]Interceptor{tracing(c.Tracer)}, interceptors...)
 	}
-	if c.AppId > 0 && c.SecretId == `` && c.SecretKey == `` {
-		values.Set(`appId`, strconv.FormatUint(c.AppId, 10))
-	} else {
-		if c.Token != `` {
-			values.Set(`Token`, c.Token)
+	ctx = context.WithValue(ctx, requestIdKey{}, atomic.AddUint64(&c.id, 1))
+	return chain(interceptors, c.invoke)(ctx, values.Get(`Action`), values)
+}
+
+// result assert result of call to response type of action, Result replaced by interceptor must implement it
+//  input: res Result
+//  input: err error
+//  return: T ResponseSM, ResponseRM, Route...
+//  return: error
+func result[T Result](res Result, err error) (T, error) {
+	r, ok := res.(T)
+	if !ok && res != nil && err == nil {
+		err = fmt.Errorf("unexpected result type %T from interceptor", res)
+	}
+	return r, err
+}
+
+// invoke send request with retry, it's the innermost Invoker of interceptors
+//  input: ctx context.Context
+//  input: action string
+//  input: values url.Values
+//  return: Result
+//  return: error
+func (c *Client) invoke(ctx context.Context, action string, values url.Values) (Result, error) {
+	var msg *msgResponse
+	var err error
+	id, ok := ClientRequestId(ctx)
+	if !ok {
+		id = atomic.AddUint64(&c.id, 1)
+	}
+	rl := c.requestLog(action, id)
+	if c.Inflight != nil {
+		resource := values.Get(`queueName`)
+		if resource == `` {
+			resource = values.Get(`topicName`)
+		}
+		c.Inflight.add(&InflightRequest{Id: id, Action: action, Resource: resource, Start: c.clock().Now(), clock: c.clock()})
+		defer c.Inflight.remove(id)
+	}
+	for attempt := 1; ; attempt++ {
+		if err = c.Limiter.wait(ctx, c.clock(), values); err != nil {
+			return nil, err
+		}
+		c.Inflight.attempt(id, attempt)
+		msg, err = c.failover(ctx, values, id, rl)
+		if !c.Retry.retryable(action, attempt, msg, err) || ctx.Err() != nil {
+			break
+		}
+		backoff := c.Retry.backoff(attempt, err, c.rand())
+		rl.log(ctx, LevelWarn, `retry`, `backoff`, backoff, `attempt`, attempt, `error`, err)
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("retry %s: %w", action, ctx.Err())
+		case <-timer.C:
+		}
+	}
+	if err != nil {
+		rl.log(ctx, LevelError, `request failed`, `error`, err)
+		if errors.Is(err, ErrClockSkew) {
+			return msg, err // result with the code of server
+		}
+		return nil, err
+	}
+	if c.CodeError {
+		if err = apiError(action, msg); err != nil {
+			return msg, err
 		}
-		values.Set(`SecretId`, c.SecretId)
-		values.Set(`SignatureMethod`, c.SignMethod)
-		values.Set(`Nonce`, strconv.FormatUint(uint64(rand.Uint32()), 10))
-		values.Set(`Timestamp`, strconv.FormatInt(time.Now().Unix(), 10))
-		values.Set(`Signature`, c.sign(c.Url.Host, values))
 	}
+	return msg, nil
+}
+
+// credential get credential from provider or fields of client
+//  input: ctx context.Context
+//  return: *Credential
+//  return: error
+func (c *Client) credential(ctx context.Context) (*Credential, error) {
+	if c.Credential != nil {
+		return c.Credential.Credential(ctx)
+	}
+	return &Credential{SecretId: c.SecretId, SecretKey: c.SecretKey, Token: c.Token}, nil
+}
+
+// do send single request, the returned msg may be not nil with *HTTPError for non-2xx response
+//  input: ctx context.Context
+//  input: values url.Values
+//  input: id uint64 clientRequestId
+//  input: rl *requestLog
+//  return: *msgResponse
+//  return: error
+func (c *Client) do(ctx context.Context, values url.Values, id uint64, rl *requestLog) (msg *msgResponse, err error) {
+	caller := ctx
+	if t := c.timeout(values); t > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t)
+		defer cancel()
+	}
+	base, ep := c.endpoint(ctx, values)
+	cc, err := c.Breaker.allow(base, values.Get(`Action`))
+	if err != nil {
+		return nil, err
+	}
+	var sent bool
+	defer func() {
+		if sent {
+			c.Breaker.report(caller, cc, msg, err)
+		} else {
+			c.Breaker.release(cc) // failed before sending, ex: credential or url length
+		}
+	}()
+
+	r, err := c.authRequest(ctx, values, id, c.method(values.Get(`Action`)), base, rl)
+	if err != nil {
+		return nil, err
+	}
+	if r.Method == http.MethodGet {
+		if err = c.checkURL(base, r.e.query); err != nil {
+			r.e.free()
+			if c.Method != MethodAuto {
+				return nil, err
+			}
+			// fall back to POST, authenticate again for the changed method
+			if r, err = c.authRequest(ctx, values, id, http.MethodPost, base, rl); err != nil {
+				return nil, err
+			}
+		}
+	}
+	e := r.e
 
 	// https://cloud.tencent.com/document/product/406/5906
-	var query string
-	var reader io.Reader
-	var u *url.URL
-	switch c.Method {
+	var req *http.Request
+	switch r.Method {
 	case http.MethodGet:
 		// 请求方法是GET，对所有请求参数值做URL编码
-		query = values.Encode()
-		u = &(*c.Url) // copy url
-		u.RawQuery = query
+		u := *base // copy url, keep client safe for concurrent use
+		u.RawQuery = string(e.query)
+		req, err = http.NewRequestWithContext(ctx, r.Method, u.String(), nil)
 	case http.MethodPost:
-		u = c.Url // reference url, avoid memory copy
-		var plain []string
-		for k, v := range values {
-			if len(v) > 0 {
-				v[0] = url.QueryEscape(strings.Join(v, ``))
-			}
-			plain = append(plain, k, `=`, strings.Join(v, ``), `&`)
+		b := &body{e: e}
+		b.Reset(e.query)
+		req, err = http.NewRequestWithContext(ctx, r.Method, base.String(), b)
+		if err == nil {
+			req.ContentLength = b.Size()
 		}
-		if plain[len(plain)-1] == `&` {
-			plain = plain[:len(plain)-1]
-		}
-		query = strings.Join(plain, ``)
-		reader = strings.NewReader(query)
 	default:
-		return nil, errors.New("unsupported request method: " + c.Method)
+		err = errors.New("unsupported request method: " + r.Method)
 	}
-	var req *http.Request
-	req, err = http.NewRequest(c.Method, u.String(), reader)
 	if err != nil {
+		e.free()
 		return nil, fmt.Errorf("new http request: %w", err)
 	}
-	req.Header.Set(`Content-Type`, `application/x-www-form-urlencoded`)
-	for k, v := range c.Header {
-		req.Header.Set(k, v)
+	req.Header = r.Header
+	if rl.enabled(LevelDebug) {
+		rl.log(ctx, LevelDebug, `request`, `method`, r.Method, `url`, base.String(), `params`, rl.values(e.values()).Encode())
 	}
-	var resp *http.Response
-	if c.Debug {
-		fmt.Printf("curl -i -X %s -H 'Content-Type:application/x-www-form-urlencoded' '%s'", c.Method, u.String())
-		if c.Method == http.MethodPost {
-			fmt.Printf(" -d '%s'", query)
-		}
-		fmt.Println()
+	if r.Method == http.MethodGet {
+		e.free() // query is copied into url
 	}
+	var resp *http.Response
+	start := c.clock().Now()
+	sent = true
 	resp, err = c.HttpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("http client do request: %w", err)
+	end := c.clock().Now()
+	if ep != nil && !cancelled(caller, err) {
+		ep.report(err == nil && resp.StatusCode < 500, end.Sub(start))
 	}
-	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("read response body: %w", err)
-	}
-	raw := string(data)
-	if c.Debug {
-		fmt.Println("Status:", resp.StatusCode)
-		fmt.Println("Response:", raw)
-	}
-	msg = &msgResponse{
-		Status: resp.StatusCode,
-		Raw:    raw,
+		return nil, fmt.Errorf("http client do request: %w", err)
 	}
-	if !json.Valid(data) {
-		return nil, fmt.Errorf("got invalid json response: %s", raw)
+	c.trackSkew(resp, start, end)
+	defer resp.Body.Close()
+	msg = &msgResponse{Status: resp.StatusCode, ClientId_: id}
+	debug := rl.enabled(LevelDebug)
+	err = decode(resp, int64(c.limits().MaxResponseSize), msg, debug)
+	if debug {
+		rl.log(ctx, LevelDebug, `response`, `status`, resp.StatusCode, `body`, rl.body(msg.Raw))
 	}
-	err = json.Unmarshal(data, msg)
 	if err != nil {
-		return nil, fmt.Errorf("json decode: %w, response: %s", err, raw)
+		var he *HTTPError
+		if errors.As(err, &he) {
+			he.Action = values.Get(`Action`)
+		}
+		return msg, err
+	}
+	if err = c.skewError(values.Get(`Action`), msg); err != nil {
+		return msg, err
 	}
 	return msg, nil
 }
+
+// cancelled check whether request failed by cancellation or deadline of the caller's context
+//  input: ctx context.Context caller's context
+//  input: err error
+//  return: bool
+func cancelled(ctx context.Context, err error) bool {
+	return ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded))
+}
@@ -0,0 +1,148 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestServer CMQ gateway answers every action with code 0, receive waits shortly to overlap with sends
+func newTestServer(tb testing.TB) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		switch r.Form.Get(`Action`) {
+		case actionRecvMsg:
+			time.Sleep(time.Millisecond)
+			_, _ = w.Write([]byte(`{"code":0,"msgId":"m","msgBody":"hello","receiptHandle":"h"}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":0,"msgId":"m","requestId":"r"}`))
+		}
+	}))
+	tb.Cleanup(srv.Close)
+	return srv
+}
+
+// TestConcurrentSendReceive run with -race, receive must not change the timeout of concurrent send
+func TestConcurrentSendReceive(t *testing.T) {
+	srv := newTestServer(t)
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := c.SendMessage(`queue`+strconv.Itoa(i), `message`, 0); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := c.ReceiveMessage(`queue`+strconv.Itoa(i), 3); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	if c.HttpClient.Timeout != 0 {
+		t.Errorf("shared http client timeout changed: %s", c.HttpClient.Timeout)
+	}
+}
+
+type cachedSM struct{ Result }
+
+func (cachedSM) MsgId() string { return `cached` }
+
+// TestInterceptorReplaceResult interceptor short-circuits with its own Result implementation
+func TestInterceptorReplaceResult(t *testing.T) {
+	c, err := NewClient(`http://127.0.0.1:1`, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Interceptors = []Interceptor{func(ctx context.Context, action string, values url.Values, invoke Invoker) (Result, error) {
+		return cachedSM{}, nil
+	}}
+	res, err := c.SendMessage(`queue`, `message`, 0)
+	if err != nil || res.MsgId() != `cached` {
+		t.Fatalf("result not replaced: %v %v", res, err)
+	}
+	if _, err = c.ReceiveMessage(`queue`, 0); err == nil {
+		t.Fatal("expect error for result not implementing ResponseRM")
+	}
+}
+
+// BenchmarkSendMessage allocations of encoding, signing and decoding for each SendMessage
+func BenchmarkSendMessage(b *testing.B) {
+	srv := newTestServer(b)
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = c.SendMessage(`queue`, `hello world message`, 0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestMethodAuto route queries as GET, messages as POST, POST when GET url is too long
+func TestMethodAuto(t *testing.T) {
+	var mu sync.Mutex
+	var methods []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		methods = append(methods, r.Method+` `+r.Form.Get(`Action`))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":0,"msgId":"m"}`))
+	}))
+	defer srv.Close()
+	c, err := NewClientWithOptions(srv.URL, WithSecret(`AKIDtest`, `secret`), WithMethod(MethodAuto))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.QueryQueueRoute(`queue`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.SendMessage(`queue`, `message`, 0); err != nil {
+		t.Fatal(err)
+	}
+	limits := DefaultLimits()
+	limits.MaxURLLength = len(srv.URL) + 32
+	c.Limits = &limits
+	if _, err = c.QueryQueueRoute(`queue`); err != nil {
+		t.Fatal(err)
+	}
+	expect := []string{`GET QueryQueueRoute`, `POST SendMessage`, `POST QueryQueueRoute`}
+	if strings.Join(methods, `,`) != strings.Join(expect, `,`) {
+		t.Fatalf("methods %v, expect %v", methods, expect)
+	}
+
+	c.Method = http.MethodGet
+	if _, err = c.QueryQueueRoute(`queue`); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("explicit GET over MaxURLLength: %v", err)
+	}
+	if len(methods) != len(expect) {
+		t.Fatalf("request sent: %v", methods)
+	}
+}
@@ -0,0 +1,86 @@
+package tdmq
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type (
+	// Clock source of Timestamp for signed request
+	Clock interface {
+		Now() time.Time
+	}
+
+	// Rand source of Nonce, clientRequestId, retry jitter and log sampling, must be safe for concurrent use
+	Rand interface {
+		Uint32() uint32
+		Int63n(n int64) int64
+		Float64() float64
+	}
+)
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time { return time.Now() }
+
+// lockedRand Rand safe for concurrent use, keep global math/rand untouched
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// NewRand create Rand safe for concurrent use, seed 0 means random seed
+//  input: seed int64
+//  return: Rand
+func NewRand(seed int64) Rand {
+	if seed == 0 {
+		var b [8]byte
+		if _, err := crand.Read(b[:]); err == nil {
+			seed = int64(binary.LittleEndian.Uint64(b[:]))
+		} else {
+			seed = time.Now().UnixNano()
+		}
+	}
+	return &lockedRand{r: rand.New(rand.NewSource(seed))}
+}
+
+func (l *lockedRand) Uint32() uint32 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Uint32()
+}
+
+func (l *lockedRand) Int63n(n int64) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Int63n(n)
+}
+
+func (l *lockedRand) Float64() float64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Float64()
+}
+
+var defaultRand = NewRand(0) // for client not created by constructors
+
+// clock of client, default: system clock
+//  return: Clock
+func (c *Client) clock() Clock {
+	if c.Clock != nil {
+		return c.Clock
+	}
+	return systemClock{}
+}
+
+// rand of client, default: package level source
+//  return: Rand
+func (c *Client) rand() Rand {
+	if c.Rand != nil {
+		return c.Rand
+	}
+	return defaultRand
+}
@@ -0,0 +1,77 @@
+package tdmq
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+// TestClockInjected skew sampling and in-flight start time use Clock of client
+func TestClockInjected(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	var started time.Time
+	c := &Client{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if list := c.Inflight.List(); len(list) == 1 {
+			started = list[0].Start
+		}
+		w.Header().Set(`Date`, now.Add(time.Hour).Format(http.TimeFormat))
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer srv.Close()
+	c, err := NewClientWithOptions(srv.URL, WithSecret(`AKIDtest`, `secret`), WithClock(fixedClock(now)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Inflight = &Registry{}
+	if _, err = c.SendMessage(`queue`, `message`, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !started.Equal(now) {
+		t.Fatalf("in-flight start %s, expect %s", started, now)
+	}
+	if skew := c.ClockSkew(); skew < time.Hour || skew > time.Hour+time.Second {
+		t.Fatalf("clock skew %s, expect 1h by Date header", skew)
+	}
+}
+
+// roundTripFunc http.RoundTripper by function, serve requests without network
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestDeterministicRequest injected Clock and Rand pin Nonce, Timestamp, clientRequestId and Signature
+func TestDeterministicRequest(t *testing.T) {
+	var body string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		data, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		body = string(data)
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{"code":0,"msgId":"m"}`))}, nil
+	})
+	c, err := NewClientWithOptions(`http://gateway.tdmq.io`, WithSecret(`AKIDtest`, `secret`),
+		WithClock(fixedClock(time.Unix(1700000000, 0))), WithRand(NewRand(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.HttpClient.Transport = transport
+	if _, err = c.SendMessage(`queue`, `message`, 0); err != nil {
+		t.Fatal(err)
+	}
+	// update with RequestClient when currentVersion changes
+	expect := `Action=SendMessage&Nonce=4039455774&RequestClient=SDK_GO_1.2.0&SecretId=AKIDtest` +
+		`&Signature=BszA1Fuf8QAw2GozZ1ft8h58%2F5g%3D&SignatureMethod=HmacSHA1&Timestamp=1700000000` +
+		`&clientRequestId=2596996163&delaySeconds=0&msgBody=message&queueName=queue`
+	if body != expect {
+		t.Errorf("request body:\n%s\nexpect:\n%s", body, expect)
+	}
+}
@@ -0,0 +1,181 @@
+package tdmq
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// environment variables for EnvCredential, same as tencent cloud sdk
+const (
+	EnvSecretId  = `TENCENTCLOUD_SECRET_ID`
+	EnvSecretKey = `TENCENTCLOUD_SECRET_KEY`
+	EnvToken     = `TENCENTCLOUD_SESSION_TOKEN`
+)
+
+var ErrNoCredential = errors.New("no credential")
+
+// Credential secret to sign request
+type Credential struct {
+	SecretId   string    `json:"secretId"`
+	SecretKey  string    `json:"secretKey"`
+	Token      string    `json:"token,omitempty"`      // for temporary credential
+	Expiration time.Time `json:"expiration,omitempty"` // zero means never expire
+}
+
+// CredentialProvider provide credential for each request, must be safe for concurrent use
+type CredentialProvider interface {
+	Credential(ctx context.Context) (*Credential, error)
+}
+
+// Credential static credential provide itself
+//  input: ctx context.Context
+//  return: *Credential
+//  return: error
+func (c *Credential) Credential(context.Context) (*Credential, error) {
+	if c.SecretId == `` || c.SecretKey == `` {
+		return nil, ErrNoCredential
+	}
+	return c, nil
+}
+
+// expired check whether credential is expired in window
+//  input: window time.Duration
+//  return: bool
+func (c *Credential) expired(window time.Duration) bool {
+	return !c.Expiration.IsZero() && time.Now().Add(window).After(c.Expiration)
+}
+
+// EnvCredential read credential from environment variables EnvSecretId, EnvSecretKey and EnvToken on each request
+type EnvCredential struct{}
+
+func (EnvCredential) Credential(ctx context.Context) (*Credential, error) {
+	c := &Credential{
+		SecretId:  os.Getenv(EnvSecretId),
+		SecretKey: os.Getenv(EnvSecretKey),
+		Token:     os.Getenv(EnvToken),
+	}
+	return c.Credential(ctx)
+}
+
+// FileCredential read credential from json file, reload it when the file is modified
+//  file content ex: {"secretId":"AKIDxxxxx","secretKey":"xxxxx","token":""}
+type FileCredential struct {
+	Path     string
+	Interval time.Duration // min interval to check modification of file, default: 10s
+
+	mu      sync.Mutex
+	cred    *Credential
+	modTime time.Time
+	checked time.Time
+}
+
+func (f *FileCredential) Credential(ctx context.Context) (*Credential, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	interval := f.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if f.cred != nil && time.Since(f.checked) < interval {
+		return f.cred, nil
+	}
+	f.checked = time.Now()
+	info, err := os.Stat(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("stat credential file: %w", err)
+	}
+	if f.cred != nil && info.ModTime().Equal(f.modTime) {
+		return f.cred, nil
+	}
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read credential file: %w", err)
+	}
+	c := &Credential{}
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("decode credential file: %w", err)
+	}
+	if _, err = c.Credential(ctx); err != nil {
+		return nil, fmt.Errorf("credential file %s: %w", f.Path, err)
+	}
+	f.cred, f.modTime = c, info.ModTime()
+	return c, nil
+}
+
+// RefreshingCredential cache temporary credential, fetch a new one before it expires
+// Only one Fetch runs at a time, concurrent requests keep using the old credential or wait for the running Fetch.
+type RefreshingCredential struct {
+	Fetch  func(ctx context.Context) (*Credential, error) // ex: request STS for temporary secret and token
+	Window time.Duration                                  // refresh ahead of expiration, default: 5m
+
+	mu    sync.Mutex
+	cred  *Credential
+	fetch *fetchCall // running Fetch, nil when idle
+}
+
+// fetchCall result of Fetch shared by concurrent requests
+type fetchCall struct {
+	done chan struct{} // closed when Fetch returns
+	cred *Credential
+	err  error
+}
+
+func (r *RefreshingCredential) Credential(ctx context.Context) (*Credential, error) {
+	window := r.Window
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	r.mu.Lock()
+	old, call := r.cred, r.fetch
+	if old != nil && !old.expired(window) {
+		r.mu.Unlock()
+		return old, nil
+	}
+	if call == nil {
+		call = &fetchCall{done: make(chan struct{})}
+		r.fetch = call
+		r.mu.Unlock()
+		r.refresh(ctx, call)
+	} else {
+		r.mu.Unlock()
+		if old != nil && !old.expired(0) {
+			return old, nil // refreshing by another request
+		}
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("fetch credential: %w", ctx.Err())
+		}
+	}
+	if call.err != nil {
+		if old != nil && !old.expired(0) {
+			return old, nil // keep using the old one until it's really expired
+		}
+		return nil, call.err
+	}
+	return call.cred, nil
+}
+
+// refresh run Fetch without holding the lock, publish the result to waiting requests
+//  input: ctx context.Context
+//  input: call *fetchCall
+func (r *RefreshingCredential) refresh(ctx context.Context, call *fetchCall) {
+	c, err := r.Fetch(ctx)
+	if err == nil {
+		_, err = c.Credential(ctx)
+	}
+	r.mu.Lock()
+	if err != nil {
+		call.err = fmt.Errorf("fetch credential: %w", err)
+	} else {
+		call.cred, r.cred = c, c
+	}
+	r.fetch = nil
+	r.mu.Unlock()
+	close(call.done)
+}
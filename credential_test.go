@@ -0,0 +1,123 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestFileCredential reload credential file when its mtime changed
+func TestFileCredential(t *testing.T) {
+	path := filepath.Join(t.TempDir(), `credential.json`)
+	write := func(id string, mtime time.Time) {
+		if err := os.WriteFile(path, []byte(`{"secretId":"`+id+`","secretKey":"key"}`), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mtime := time.Now().Add(-time.Hour)
+	write(`AKID1`, mtime)
+	f := &FileCredential{Path: path, Interval: time.Hour}
+	get := func(expect string) {
+		t.Helper()
+		c, err := f.Credential(context.Background())
+		if err != nil || c.SecretId != expect {
+			t.Fatalf("credential %v %v, expect %s", c, err, expect)
+		}
+	}
+	get(`AKID1`)
+
+	write(`AKID2`, mtime.Add(time.Minute))
+	get(`AKID1`) // not checked in Interval
+
+	f.Interval = time.Nanosecond
+	get(`AKID2`)
+
+	write(`AKID3`, mtime.Add(time.Minute))
+	get(`AKID2`) // same mtime, not reloaded
+
+	write(`AKID4`, mtime.Add(2*time.Minute))
+	get(`AKID4`)
+}
+
+// TestRefreshingCredential refresh in Window, keep the old credential when Fetch fails
+func TestRefreshingCredential(t *testing.T) {
+	var fetched int32
+	var fail atomic.Value
+	fail.Store(false)
+	expiration := time.Now().Add(time.Hour)
+	r := &RefreshingCredential{
+		Window: 10 * time.Minute,
+		Fetch: func(context.Context) (*Credential, error) {
+			if fail.Load().(bool) {
+				return nil, errors.New("sts unavailable")
+			}
+			atomic.AddInt32(&fetched, 1)
+			return &Credential{SecretId: `AKID`, SecretKey: `key`, Expiration: expiration}, nil
+		},
+	}
+	c1, err := r.Credential(context.Background())
+	if err != nil || atomic.LoadInt32(&fetched) != 1 {
+		t.Fatalf("first fetch: %v %v", c1, err)
+	}
+	if c, _ := r.Credential(context.Background()); c != c1 || atomic.LoadInt32(&fetched) != 1 {
+		t.Fatal("credential out of Window is cached")
+	}
+
+	expiration = time.Now().Add(5 * time.Minute)
+	c1.Expiration = expiration // in Window
+	c2, err := r.Credential(context.Background())
+	if err != nil || c2 == c1 || atomic.LoadInt32(&fetched) != 2 {
+		t.Fatalf("refresh in Window: %v %v", c2, err)
+	}
+
+	fail.Store(true)
+	if c, err := r.Credential(context.Background()); err != nil || c != c2 {
+		t.Fatalf("keep old credential when Fetch fails: %v %v", c, err)
+	}
+	c2.Expiration = time.Now().Add(-time.Second)
+	if _, err = r.Credential(context.Background()); err == nil {
+		t.Fatal("expired credential used when Fetch fails")
+	}
+}
+
+// TestRefreshingCredentialConcurrent single Fetch for concurrent requests, the lock is not held during Fetch
+func TestRefreshingCredentialConcurrent(t *testing.T) {
+	var fetched int32
+	release := make(chan struct{})
+	r := &RefreshingCredential{Fetch: func(context.Context) (*Credential, error) {
+		atomic.AddInt32(&fetched, 1)
+		<-release
+		return &Credential{SecretId: `AKID`, SecretKey: `key`}, nil
+	}}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if c, err := r.Credential(context.Background()); err != nil || c.SecretId != `AKID` {
+				t.Errorf("credential %v %v", c, err)
+			}
+		}()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	for atomic.LoadInt32(&fetched) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := r.Credential(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("waiting request cancelled: %v", err)
+	}
+	close(release)
+	wg.Wait()
+	if n := atomic.LoadInt32(&fetched); n != 1 {
+		t.Errorf("fetched %d times", n)
+	}
+}
@@ -0,0 +1,93 @@
+package tdmq
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var ErrResponseTooLarge = errors.New("response too large")
+
+// limitReader read at most n bytes, fail with ErrResponseTooLarge when there are more
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n <= 0 {
+		// probe one byte to distinguish exact size from oversize
+		var b [1]byte
+		if n, _ := l.r.Read(b[:]); n > 0 {
+			return 0, ErrResponseTooLarge
+		}
+		return 0, io.EOF
+	}
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	return n, err
+}
+
+// headWriter keep the first 512 bytes written
+type headWriter struct {
+	buf [512]byte
+	n   int
+}
+
+func (w *headWriter) Write(p []byte) (int, error) {
+	w.n += copy(w.buf[w.n:], p)
+	return len(p), nil
+}
+
+// decode parse response body into msg by streaming decoder
+//  the raw body is only kept in msg when keep is true, msg.String() marshal it on demand otherwise,
+//  only the first 512 bytes are kept for *HTTPError otherwise.
+//  non-JSON response and non-2xx response without CMQ error code are returned as *HTTPError
+//  input: resp *http.Response
+//  input: max int64 max size of response body, <=0 means unlimited
+//  input: msg *msgResponse
+//  input: keep bool
+//  return: error
+func decode(resp *http.Response, max int64, msg *msgResponse, keep bool) error {
+	var r io.Reader = resp.Body
+	if max > 0 {
+		r = &limitReader{r: r, n: max}
+	}
+	var raw strings.Builder
+	head := &headWriter{}
+	if keep {
+		r = io.TeeReader(r, &raw)
+	} else {
+		r = io.TeeReader(r, head)
+	}
+	body := func() []byte {
+		if keep {
+			msg.Raw = raw.String()
+			return []byte(msg.Raw)
+		}
+		return head.buf[:head.n]
+	}
+	err := json.NewDecoder(r).Decode(msg)
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case err == nil:
+	case errors.As(err, &syntax), errors.As(err, &typ), err == io.EOF, err == io.ErrUnexpectedEOF:
+		_, _ = io.Copy(io.Discard, io.LimitReader(r, int64(len(head.buf)))) // body of error page
+		return httpError(resp, body(), fmt.Errorf("json decode: %w", err))
+	default:
+		body()
+		return fmt.Errorf("read response body: %w", err)
+	}
+	b := body()
+	if (resp.StatusCode < 200 || resp.StatusCode > 299) && msg.Code_ == CodeSuccess {
+		return httpError(resp, b, nil) // no CMQ error code in response
+	}
+	return nil
+}
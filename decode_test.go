@@ -0,0 +1,34 @@
+package tdmq
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func response(status int, body string) *http.Response {
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))}
+}
+
+// TestDecode streaming decode with size limit and error page head
+func TestDecode(t *testing.T) {
+	msg := &msgResponse{}
+	if err := decode(response(200, `{"code":0,"msgId":"m"}`), 1024, msg, false); err != nil || msg.MsgId_ != `m` || msg.Raw != `` {
+		t.Fatalf("decode: %v %+v", err, msg)
+	}
+	err := decode(response(200, `{"msgId":"`+strings.Repeat(`x`, 2048)+`"}`), 1024, &msgResponse{}, false)
+	if !errors.Is(err, ErrResponseTooLarge) {
+		t.Fatalf("expect ErrResponseTooLarge: %v", err)
+	}
+	var he *HTTPError
+	err = decode(response(502, `<html>`+strings.Repeat(`x`, 2048)), 0, &msgResponse{}, false)
+	if !errors.As(err, &he) || len(he.Body) != 512 || he.Status != 502 {
+		t.Fatalf("expect HTTPError with body head: %v", err)
+	}
+	msg = &msgResponse{}
+	if err = decode(response(200, `{"code":0}`), 0, msg, true); err != nil || msg.Raw != `{"code":0}` {
+		t.Fatalf("raw body not kept: %v %q", err, msg.Raw)
+	}
+}
@@ -0,0 +1,242 @@
+package tdmq
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// param of request
+type param struct {
+	key   string
+	value string
+}
+
+// encoder build sorted request params, string to sign and encoded query in reused buffers
+type encoder struct {
+	params []param
+	plain  []byte // string to sign
+	query  []byte // url encoded params
+}
+
+// maxHMACPools max number of sign method and secret key pairs with pooled hmac
+const maxHMACPools = 16
+
+var (
+	encoderPool = sync.Pool{New: func() any { return &encoder{} }}
+
+	hmacMu    sync.Mutex
+	hmacPools = map[hmacKey]*sync.Pool{}
+)
+
+// hmacKey sign method and secret key of pooled hmac
+type hmacKey struct {
+	method string
+	secret string
+}
+
+// hmacState reusable keyed hmac and buffer of sum
+type hmacState struct {
+	h   hash.Hash
+	sum [sha256.Size]byte
+}
+
+// newEncoder get encoder from pool with params copied from values, values are not changed
+//  input: values url.Values
+//  return: *encoder
+func newEncoder(values url.Values) *encoder {
+	e := encoderPool.Get().(*encoder)
+	for k, v := range values {
+		if len(v) == 1 {
+			e.params = append(e.params, param{key: k, value: v[0]})
+		} else {
+			e.params = append(e.params, param{key: k, value: strings.Join(v, ``)})
+		}
+	}
+	return e
+}
+
+// free put encoder back to pool, the buffers must not be used after free
+func (e *encoder) free() {
+	if cap(e.plain) > 64*1024 || cap(e.query) > 64*1024 {
+		return // avoid holding large message bodies in pool
+	}
+	e.params, e.plain, e.query = e.params[:0], e.plain[:0], e.query[:0]
+	encoderPool.Put(e)
+}
+
+// set param, replace the existing one
+//  input: key string
+//  input: value string
+func (e *encoder) set(key, value string) {
+	for i := range e.params {
+		if e.params[i].key == key {
+			e.params[i].value = value
+			return
+		}
+	}
+	e.params = append(e.params, param{key: key, value: value})
+}
+
+// sort params by key once, signature and query share the same order
+func (e *encoder) sort() {
+	sort.Sort(e)
+}
+
+// insert param into sorted params, replace the existing one
+//  input: key string
+//  input: value string
+func (e *encoder) insert(key, value string) {
+	i := sort.Search(len(e.params), func(i int) bool { return e.params[i].key >= key })
+	if i < len(e.params) && e.params[i].key == key {
+		e.params[i].value = value
+		return
+	}
+	e.params = append(e.params, param{})
+	copy(e.params[i+1:], e.params[i:])
+	e.params[i] = param{key: key, value: value}
+}
+
+func (e *encoder) Len() int           { return len(e.params) }
+func (e *encoder) Less(i, j int) bool { return e.params[i].key < e.params[j].key }
+func (e *encoder) Swap(i, j int)      { e.params[i], e.params[j] = e.params[j], e.params[i] }
+
+// stringToSign build plain text of sorted params for signature: METHOD host path ? k=v&k=v
+//  input: method string
+//  input: u *url.URL
+//  return: []byte valid until free
+func (e *encoder) stringToSign(method string, u *url.URL) []byte {
+	b := append(e.plain[:0], method...)
+	b = append(b, u.Host...)
+	path := u.Path
+	if idx := strings.IndexByte(path, '?'); idx >= 0 {
+		path = path[:idx]
+	}
+	b = append(b, path...)
+	b = append(b, '?')
+	for i, p := range e.params {
+		if i > 0 {
+			b = append(b, '&')
+		}
+		for j := 0; j < len(p.key); j++ {
+			if p.key[j] == '_' {
+				b = append(b, '.')
+			} else {
+				b = append(b, p.key[j])
+			}
+		}
+		b = append(b, '=')
+		b = append(b, p.value...)
+	}
+	e.plain = b
+	return b
+}
+
+// encode sorted params as url query
+//  return: []byte valid until free
+func (e *encoder) encode() []byte {
+	b := e.query[:0]
+	for i, p := range e.params {
+		if i > 0 {
+			b = append(b, '&')
+		}
+		b = appendEscape(b, p.key)
+		b = append(b, '=')
+		b = appendEscape(b, p.value)
+	}
+	e.query = b
+	return b
+}
+
+// values copy params, only for logging
+//  return: url.Values
+func (e *encoder) values() url.Values {
+	values := make(url.Values, len(e.params))
+	for _, p := range e.params {
+		values.Set(p.key, p.value)
+	}
+	return values
+}
+
+// appendEscape append s escaped same as url.QueryEscape
+//  input: b []byte
+//  input: s string
+//  return: []byte
+func appendEscape(b []byte, s string) []byte {
+	const hex = `0123456789ABCDEF`
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case 'a' <= ch && ch <= 'z', 'A' <= ch && ch <= 'Z', '0' <= ch && ch <= '9',
+			ch == '-', ch == '_', ch == '.', ch == '~':
+			b = append(b, ch)
+		case ch == ' ':
+			b = append(b, '+')
+		default:
+			b = append(b, '%', hex[ch>>4], hex[ch&15])
+		}
+	}
+	return b
+}
+
+// signature of plain text by sign method HmacSHA1 or HmacSHA256
+//  input: method string
+//  input: key string
+//  input: plain []byte
+//  return: string
+func signature(method, key string, plain []byte) string {
+	pool := hmacPool(method, key)
+	s := pool.Get().(*hmacState)
+	defer pool.Put(s)
+	s.h.Reset()
+	s.h.Write(plain)
+	return base64.StdEncoding.EncodeToString(s.h.Sum(s.sum[:0]))
+}
+
+// hmacPool pool of hmac keyed by secret, a random one is dropped when there are maxHMACPools, ex: rotated keys
+//  input: method string
+//  input: key string
+//  return: *sync.Pool
+func hmacPool(method, key string) *sync.Pool {
+	k := hmacKey{method: method, secret: key}
+	hmacMu.Lock()
+	defer hmacMu.Unlock()
+	if pool, ok := hmacPools[k]; ok {
+		return pool
+	}
+	if len(hmacPools) >= maxHMACPools {
+		for old := range hmacPools {
+			delete(hmacPools, old)
+			break
+		}
+	}
+	newHash := sha1.New
+	if method == HmacSHA256 {
+		newHash = sha256.New
+	}
+	secret := []byte(key)
+	pool := &sync.Pool{New: func() any { return &hmacState{h: hmac.New(newHash, secret)} }}
+	hmacPools[k] = pool
+	return pool
+}
+
+// body of POST request, release encoder when it's closed by http transport
+type body struct {
+	bytes.Reader
+	e      *encoder
+	closed int32
+}
+
+func (b *body) Close() error {
+	if atomic.CompareAndSwapInt32(&b.closed, 0, 1) {
+		b.e.free()
+	}
+	return nil
+}
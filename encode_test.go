@@ -0,0 +1,50 @@
+package tdmq
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// TestSignature same as crypto/hmac for short and long secret keys
+func TestSignature(t *testing.T) {
+	plain := []byte(`POSTgateway/?Action=SendMessage&msgBody=hello`)
+	for method, newHash := range map[string]func() hash.Hash{HmacSHA1: sha1.New, HmacSHA256: sha256.New} {
+		for _, key := range []string{`secret`, strings.Repeat(`k`, 64), strings.Repeat(`long`, 40)} {
+			h := hmac.New(newHash, []byte(key))
+			h.Write(plain)
+			expect := base64.StdEncoding.EncodeToString(h.Sum(nil))
+			if got := signature(method, key, plain); got != expect {
+				t.Errorf("%s key length %d: %s, expect %s", method, len(key), got, expect)
+			}
+		}
+	}
+}
+
+// BenchmarkEncoder sorting params, building string to sign and url encoded query in pooled encoder
+func BenchmarkEncoder(b *testing.B) {
+	values := url.Values{
+		`Action`:    {actionSendMsg},
+		`queueName`: {`queue`},
+		`msgBody`:   {`hello world message`},
+		`Nonce`:     {`1234567890`},
+		`Timestamp`: {`1700000000`},
+		`SecretId`:  {`AKIDtest`},
+	}
+	u := &url.URL{Scheme: `http`, Host: `gateway`, Path: `/`}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		e := newEncoder(values)
+		e.set(`clientRequestId`, `1`)
+		e.sort()
+		e.stringToSign(http.MethodPost, u)
+		e.encode()
+		e.free()
+	}
+}
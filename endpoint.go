@@ -0,0 +1,257 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+// Balance strategy to pick endpoint for each request
+type Balance int
+
+const (
+	RoundRobin     Balance = iota // pick healthy endpoints in turn
+	LeastLatency                  // pick healthy endpoint with the least average latency
+	ConsistentHash                // pick healthy endpoint by queue/topic name, keep the same resource on the same endpoint
+)
+
+// Endpoints multiple gateway endpoints with passive health checking and failover
+//  an endpoint is unhealthy after MaxFails consecutive transport errors or 5xx responses,
+//  unhealthy endpoints are skipped until a request or an active probe (see Client.Probe) succeeds on it,
+//  all endpoints are used when none of them is healthy.
+//  request not sent on the picked endpoint, ex: connection refused or open circuit, is sent to the next endpoint
+//  regardless of RetryPolicy, even for SendMessage and PublishMessage.
+type Endpoints struct {
+	Balance       Balance
+	MaxFails      int           // consecutive failures to mark endpoint unhealthy, default: 3
+	ProbeQueue    string        // queue name to probe unhealthy endpoint by QueryQueueRoute
+	ProbeInterval time.Duration // interval of active probe, default: 10s
+
+	list []*endpoint
+	next uint32
+}
+
+type endpoint struct {
+	url     *url.URL
+	fails   int32 // consecutive failures
+	latency int64 // moving average latency in nanoseconds
+}
+
+type endpointKey struct{}
+
+// NewEndpoints create endpoints with balance strategy
+//  input: balance Balance
+//  input: uris ...string request uri of gateways
+//  return: *Endpoints
+//  return: error
+func NewEndpoints(balance Balance, uris ...string) (*Endpoints, error) {
+	if len(uris) == 0 {
+		return nil, errors.New("no endpoint")
+	}
+	e := &Endpoints{Balance: balance}
+	for _, uri := range uris {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return nil, fmt.Errorf("parse url: %w", err)
+		}
+		if len(u.Path) == 0 {
+			u.Path = `/`
+		}
+		e.list = append(e.list, &endpoint{url: u})
+	}
+	return e, nil
+}
+
+// Healthy list url of healthy endpoints
+//  return: []string
+func (e *Endpoints) Healthy() (urls []string) {
+	for _, ep := range e.healthy() {
+		urls = append(urls, ep.url.String())
+	}
+	return
+}
+
+// unhealthy check whether endpoint reached MaxFails consecutive failures
+//  input: ep *endpoint
+//  return: bool
+func (e *Endpoints) unhealthy(ep *endpoint) bool {
+	max := int32(e.MaxFails)
+	if max <= 0 {
+		max = 3
+	}
+	return atomic.LoadInt32(&ep.fails) >= max
+}
+
+// healthy endpoints not excluded, or all endpoints not excluded when none is healthy
+//  input: exclude ...*endpoint endpoints already tried
+//  return: []*endpoint
+func (e *Endpoints) healthy(exclude ...*endpoint) []*endpoint {
+	list := make([]*endpoint, 0, len(e.list))
+	for _, ep := range e.list {
+		if !e.unhealthy(ep) && !contains(exclude, ep) {
+			list = append(list, ep)
+		}
+	}
+	if len(list) > 0 {
+		return list
+	}
+	if len(exclude) == 0 {
+		return e.list
+	}
+	for _, ep := range e.list {
+		if !contains(exclude, ep) {
+			list = append(list, ep)
+		}
+	}
+	return list
+}
+
+// contains check whether endpoint is in list
+//  input: list []*endpoint
+//  input: ep *endpoint
+//  return: bool
+func contains(list []*endpoint, ep *endpoint) bool {
+	for _, v := range list {
+		if v == ep {
+			return true
+		}
+	}
+	return false
+}
+
+// pick endpoint for request
+//  input: values url.Values
+//  input: exclude []*endpoint endpoints already tried
+//  return: *endpoint
+func (e *Endpoints) pick(values url.Values, exclude []*endpoint) *endpoint {
+	list := e.healthy(exclude...)
+	if len(list) == 1 {
+		return list[0]
+	}
+	switch e.Balance {
+	case LeastLatency:
+		best := list[0]
+		for _, ep := range list[1:] {
+			if atomic.LoadInt64(&ep.latency) < atomic.LoadInt64(&best.latency) {
+				best = ep
+			}
+		}
+		return best
+	case ConsistentHash:
+		key := values.Get(`queueName`)
+		if key == `` {
+			key = values.Get(`topicName`)
+		}
+		// rendezvous hashing, only resources on the failed endpoint are moved
+		var best *endpoint
+		var score uint32
+		for _, ep := range list {
+			if s := crc32.ChecksumIEEE([]byte(key + ep.url.Host + ep.url.Path)); best == nil || s > score {
+				best, score = ep, s
+			}
+		}
+		return best
+	}
+	return list[atomic.AddUint32(&e.next, 1)%uint32(len(list))]
+}
+
+// report result of request on endpoint
+//  input: ok bool
+//  input: latency time.Duration
+func (ep *endpoint) report(ok bool, latency time.Duration) {
+	if !ok {
+		atomic.AddInt32(&ep.fails, 1)
+		return
+	}
+	atomic.StoreInt32(&ep.fails, 0)
+	if old := atomic.LoadInt64(&ep.latency); old > 0 {
+		atomic.StoreInt64(&ep.latency, old-old/5+int64(latency)/5)
+	} else {
+		atomic.StoreInt64(&ep.latency, int64(latency))
+	}
+}
+
+// endpoint pick endpoint url for request, pinned endpoint in ctx is preferred
+//  input: ctx context.Context
+//  input: values url.Values
+//  return: *url.URL
+//  return: *endpoint nil when Client.Endpoints is not set
+func (c *Client) endpoint(ctx context.Context, values url.Values) (*url.URL, *endpoint) {
+	if ep, ok := ctx.Value(endpointKey{}).(*endpoint); ok {
+		return ep.url, ep
+	}
+	if c.Endpoints == nil || len(c.Endpoints.list) == 0 {
+		return c.Url, nil
+	}
+	ep := c.Endpoints.pick(values, nil)
+	return ep.url, ep
+}
+
+// failover send request, try the next endpoint when it's not sent on the picked one
+//  input: ctx context.Context
+//  input: values url.Values
+//  input: id uint64 clientRequestId
+//  input: rl *requestLog
+//  return: *msgResponse
+//  return: error
+func (c *Client) failover(ctx context.Context, values url.Values, id uint64, rl *requestLog) (*msgResponse, error) {
+	e := c.Endpoints
+	if e == nil || len(e.list) < 2 || ctx.Value(endpointKey{}) != nil {
+		return c.do(ctx, values, id, rl)
+	}
+	tried := make([]*endpoint, 0, len(e.list))
+	for {
+		ep := e.pick(values, tried)
+		tried = append(tried, ep)
+		msg, err := c.do(context.WithValue(ctx, endpointKey{}, ep), values, id, rl)
+		if !unsent(err) || len(tried) == len(e.list) || ctx.Err() != nil {
+			return msg, err
+		}
+		rl.log(ctx, LevelWarn, `failover`, `endpoint`, ep.url.String(), `error`, err)
+	}
+}
+
+// unsent check whether request failed before it's written to endpoint, it's safe to send again
+//  input: err error
+//  return: bool
+func unsent(err error) bool {
+	var op *net.OpError
+	return errors.Is(err, ErrCircuitOpen) || errors.As(err, &op) && op.Op == `dial`
+}
+
+// Probe actively check unhealthy endpoints by QueryQueueRoute until ctx is done
+//  nothing to do when Endpoints or Endpoints.ProbeQueue is not set.
+//  input: ctx context.Context
+func (c *Client) Probe(ctx context.Context) {
+	e := c.Endpoints
+	if e == nil || e.ProbeQueue == `` {
+		return
+	}
+	interval := e.ProbeInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, ep := range e.list {
+			if !e.unhealthy(ep) {
+				continue
+			}
+			values := url.Values{}
+			values.Set(`Action`, actionQueueRoute)
+			values.Set(`queueName`, e.ProbeQueue)
+			_, _ = c.do(context.WithValue(ctx, endpointKey{}, ep), values, atomic.AddUint64(&c.id, 1), nil) // result is reported in do
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package tdmq
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestProbeUnhealthyOnly healthy endpoints are not probed, unhealthy one is recovered by probe
+func TestProbeUnhealthyOnly(t *testing.T) {
+	var probes int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoints, err = NewEndpoints(RoundRobin, srv.URL+`/a`, srv.URL+`/b`); err != nil {
+		t.Fatal(err)
+	}
+	c.Endpoints.ProbeQueue, c.Endpoints.ProbeInterval = `queue`, 5*time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	c.Probe(ctx)
+	cancel()
+	if n := atomic.LoadInt32(&probes); n != 0 {
+		t.Fatalf("healthy endpoints probed %d times", n)
+	}
+
+	atomic.StoreInt32(&c.Endpoints.list[1].fails, 3)
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	c.Probe(ctx)
+	cancel()
+	if n := atomic.LoadInt32(&probes); n != 1 {
+		t.Fatalf("unhealthy endpoint probed %d times, expect 1", n)
+	}
+	if len(c.Endpoints.Healthy()) != 2 {
+		t.Fatalf("endpoint not recovered: %v", c.Endpoints.Healthy())
+	}
+}
+
+// TestFailover SendMessage not sent on dead endpoint is sent to the next one without RetryPolicy
+func TestFailover(t *testing.T) {
+	srv := newTestServer(t)
+	ln, err := net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dead := `http://` + ln.Addr().String()
+	_ = ln.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoints, err = NewEndpoints(RoundRobin, dead, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 6; i++ {
+		if _, err = c.SendMessage(`queue`, `message`, 0); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if healthy := c.Endpoints.Healthy(); len(healthy) != 1 || healthy[0] != srv.URL+`/` {
+		t.Errorf("healthy endpoints: %v", healthy)
+	}
+}
+
+// TestEndpointIgnoreCancelled requests cancelled by caller don't mark endpoint unhealthy
+func TestEndpointIgnoreCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoints, err = NewEndpoints(RoundRobin, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		_, _ = c.ReceiveMessageContext(ctx, `queue`, 1)
+		cancel()
+	}
+	if n := atomic.LoadInt32(&c.Endpoints.list[0].fails); n != 0 {
+		t.Errorf("endpoint failures by cancelled requests: %d", n)
+	}
+}
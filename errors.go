@@ -0,0 +1,157 @@
+package tdmq
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CMQ response codes
+//  https://cloud.tencent.com/document/product/406/5903
+const (
+	CodeSuccess          = 0
+	CodeInvalidParameter = 4000 // 参数不合法
+	CodeAuthFailed       = 4100 // 鉴权失败
+	CodeThrottled        = 4200 // 请求过于频繁
+	CodeResourceNotExist = 4440 // 资源不存在
+	CodeInternalError    = 6000 // 服务器内部错误
+	CodeNoMessage        = 7000 // 队列中没有消息
+)
+
+var (
+	ErrNoMessage     = errors.New("no message available")
+	ErrQueueNotExist = errors.New("queue not exist")
+	ErrTopicNotExist = errors.New("topic not exist")
+	ErrAuthFailed    = errors.New("auth failed")
+	ErrThrottled     = errors.New("throttled")
+)
+
+// APIError non-zero code in response of TDMQ-CMQ, returned when Client.CodeError is enabled
+type APIError struct {
+	Action    string // request action
+	Status    int    // HTTP Response status code
+	Code      int    // CMQ response code
+	Message   string // 错误提示信息
+	RequestId string // 服务器生成的请求ID
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s code: %d, message: %s, requestId: %s", e.Action, e.Code, e.Message, e.RequestId)
+}
+
+// Is match well-known sentinel errors by response code
+//  input: target error
+//  return: bool
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNoMessage:
+		return e.Code == CodeNoMessage
+	case ErrQueueNotExist:
+		return e.Code == CodeResourceNotExist && e.Action != actionTopicRoute && e.Action != actionPubMsg && e.Action != actionBatchPub
+	case ErrTopicNotExist:
+		return e.Code == CodeResourceNotExist && (e.Action == actionTopicRoute || e.Action == actionPubMsg || e.Action == actionBatchPub)
+	case ErrAuthFailed:
+		return e.Code == CodeAuthFailed
+	case ErrThrottled:
+		return e.Code == CodeThrottled
+	}
+	return false
+}
+
+// apiError convert response with non-zero code to *APIError
+//  input: action string
+//  input: msg *msgResponse
+//  return: error
+func apiError(action string, msg *msgResponse) error {
+	if msg == nil || msg.Code_ == CodeSuccess {
+		return nil
+	}
+	return &APIError{
+		Action:    action,
+		Status:    msg.Status,
+		Code:      msg.Code_,
+		Message:   msg.Message_,
+		RequestId: msg.RequestId_,
+	}
+}
+
+// ErrorHeaders response headers kept in *HTTPError
+var ErrorHeaders = []string{`Retry-After`, `X-Request-Id`, `X-Tc-Requestid`, `Content-Type`, `Server`}
+
+// HTTPError non-2xx or non-JSON response, usually from proxy in front of the gateway
+type HTTPError struct {
+	Action     string        // request action
+	Status     int           // HTTP Response status code
+	Header     http.Header   // selected headers in ErrorHeaders
+	Body       string        // truncated response body
+	RetryAfter time.Duration // parsed from Retry-After header
+	Err        error         // json decode error
+}
+
+func (e *HTTPError) Error() string {
+	var b strings.Builder
+	b.WriteString(e.Action)
+	b.WriteString(` http status: `)
+	b.WriteString(strconv.Itoa(e.Status))
+	if e.RetryAfter > 0 {
+		b.WriteString(`, retry after: `)
+		b.WriteString(e.RetryAfter.String())
+	}
+	if e.Err != nil {
+		b.WriteString(`, error: `)
+		b.WriteString(e.Err.Error())
+	}
+	b.WriteString(`, response: `)
+	b.WriteString(e.Body)
+	return b.String()
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// Is match ErrThrottled by status 429
+//  input: target error
+//  return: bool
+func (e *HTTPError) Is(target error) bool {
+	return target == ErrThrottled && e.Status == http.StatusTooManyRequests
+}
+
+// retryable status of response
+//  return: bool
+func (e *HTTPError) retryable() bool {
+	return e.Status == http.StatusTooManyRequests || e.Status >= 500
+}
+
+// httpError create *HTTPError with selected headers and truncated body
+//  input: resp *http.Response
+//  input: body []byte
+//  input: err error
+//  return: *HTTPError
+func httpError(resp *http.Response, body []byte, err error) *HTTPError {
+	if len(body) > 512 {
+		body = body[:512]
+	}
+	e := &HTTPError{
+		Status: resp.StatusCode,
+		Header: http.Header{},
+		Body:   string(body),
+		Err:    err,
+	}
+	for _, k := range ErrorHeaders {
+		if v := resp.Header.Values(k); len(v) > 0 {
+			e.Header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	if v := resp.Header.Get(`Retry-After`); v != `` {
+		if sec, err := strconv.Atoi(v); err == nil {
+			e.RetryAfter = time.Duration(sec) * time.Second
+		} else if t, err := http.ParseTime(v); err == nil {
+			e.RetryAfter = time.Until(t)
+		}
+	}
+	return e
+}
@@ -0,0 +1,71 @@
+package tdmq
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestAPIErrorIs sentinel errors matched by code and action
+func TestAPIErrorIs(t *testing.T) {
+	sentinels := []error{ErrNoMessage, ErrQueueNotExist, ErrTopicNotExist, ErrAuthFailed, ErrThrottled}
+	cases := []struct {
+		action string
+		code   int
+		expect error
+	}{
+		{actionRecvMsg, CodeNoMessage, ErrNoMessage},
+		{actionBatchRecv, CodeNoMessage, ErrNoMessage},
+		{actionSendMsg, CodeResourceNotExist, ErrQueueNotExist},
+		{actionQueueRoute, CodeResourceNotExist, ErrQueueNotExist},
+		{actionTopicRoute, CodeResourceNotExist, ErrTopicNotExist},
+		{actionPubMsg, CodeResourceNotExist, ErrTopicNotExist},
+		{actionBatchPub, CodeResourceNotExist, ErrTopicNotExist},
+		{actionDelMsg, CodeAuthFailed, ErrAuthFailed},
+		{actionPubMsg, CodeThrottled, ErrThrottled},
+		{actionSendMsg, CodeInternalError, nil},
+	}
+	for _, c := range cases {
+		err := apiError(c.action, &msgResponse{Code_: c.code})
+		for _, s := range sentinels {
+			if got := errors.Is(err, s); got != (s == c.expect) {
+				t.Errorf("%s code %d: errors.Is(%v) %v", c.action, c.code, s, got)
+			}
+		}
+	}
+	if err := apiError(actionSendMsg, &msgResponse{Code_: CodeSuccess}); err != nil {
+		t.Errorf("success code: %v", err)
+	}
+}
+
+// TestCodeError non-zero code returned as *APIError only when Client.CodeError is enabled
+func TestCodeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":` + strconv.Itoa(CodeNoMessage) + `,"message":"no message","requestId":"r"}`))
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.ReceiveMessage(`queue`, 0)
+	if err != nil || res.Code() != CodeNoMessage {
+		t.Fatalf("CodeError disabled: %v %v", res, err)
+	}
+
+	c.CodeError = true
+	res, err = c.ReceiveMessage(`queue`, 0)
+	var ae *APIError
+	if !errors.Is(err, ErrNoMessage) || !errors.As(err, &ae) {
+		t.Fatalf("CodeError enabled: %v", err)
+	}
+	if ae.Action != actionRecvMsg || ae.Code != CodeNoMessage || ae.RequestId != `r` || ae.Status != http.StatusOK {
+		t.Errorf("api error: %+v", ae)
+	}
+	if res == nil || res.Code() != CodeNoMessage {
+		t.Errorf("result with code kept: %v", res)
+	}
+}
@@ -0,0 +1,132 @@
+package tdmq
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+)
+
+// InflightRequest request in progress
+type InflightRequest struct {
+	Id       uint64 // clientRequestId
+	Action   string
+	Resource string    // queue/topic name
+	Start    time.Time // by Clock of client
+	Attempt  int
+
+	clock Clock
+}
+
+// Registry of in-flight requests, for diagnosing stuck requests such as long polling, nil Registry is empty
+type Registry struct {
+	mu       sync.Mutex
+	requests map[uint64]*InflightRequest
+}
+
+// add request into registry
+//  input: r *InflightRequest
+func (g *Registry) add(r *InflightRequest) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	if g.requests == nil {
+		g.requests = map[uint64]*InflightRequest{}
+	}
+	g.requests[r.Id] = r
+	g.mu.Unlock()
+}
+
+// attempt update attempt of request
+//  input: id uint64
+//  input: attempt int
+func (g *Registry) attempt(id uint64, attempt int) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	if r, ok := g.requests[id]; ok {
+		r.Attempt = attempt
+	}
+	g.mu.Unlock()
+}
+
+// remove finished request
+//  input: id uint64
+func (g *Registry) remove(id uint64) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	delete(g.requests, id)
+	g.mu.Unlock()
+}
+
+// List in-flight requests, the oldest first
+//  return: []InflightRequest
+func (g *Registry) List() []InflightRequest {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	list := make([]InflightRequest, 0, len(g.requests))
+	for _, r := range g.requests {
+		list = append(list, *r)
+	}
+	g.mu.Unlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Start.Before(list[j].Start) })
+	return list
+}
+
+// Dump write in-flight requests to w
+//  input: w io.Writer
+//  return: error
+func (g *Registry) Dump(w io.Writer) error {
+	list := g.List()
+	if _, err := fmt.Fprintf(w, "%d in-flight CMQ request(s)\n", len(list)); err != nil {
+		return err
+	}
+	for _, r := range list {
+		now := time.Now()
+		if r.clock != nil {
+			now = r.clock.Now()
+		}
+		_, err := fmt.Fprintf(w, "clientRequestId=%d action=%s resource=%s attempt=%d elapsed=%s\n",
+			r.Id, r.Action, r.Resource, r.Attempt, now.Sub(r.Start).Round(time.Millisecond))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DumpOnSignal dump in-flight requests to w when signals are received, ex: syscall.SIGQUIT
+//  input: w io.Writer
+//  input: sigs ...os.Signal
+//  return: func() stop dumping
+func (g *Registry) DumpOnSignal(w io.Writer, sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+	go func() {
+		for {
+			select {
+			case <-ch:
+				_ = g.Dump(w)
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package tdmq
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestRegistryNil dump of nil registry doesn't panic
+func TestRegistryNil(t *testing.T) {
+	var g *Registry
+	var b strings.Builder
+	if err := g.Dump(&b); err != nil || !strings.HasPrefix(b.String(), `0 in-flight`) {
+		t.Fatalf("dump nil registry: %v %q", err, b.String())
+	}
+	g.DumpOnSignal(&b, os.Interrupt)()
+}
@@ -0,0 +1,69 @@
+package tdmq
+
+import (
+	"context"
+	"net/url"
+)
+
+type (
+	// Invoker invoke action with request values, return decoded result
+	Invoker func(ctx context.Context, action string, values url.Values) (Result, error)
+
+	// Interceptor wrap invoking of each action, call invoke to continue the chain.
+	//  values could be rewritten before invoke (they are signed later),
+	//  result and error could be inspected or replaced after invoke, a replaced result must implement
+	//  the response interface of action, ex: ResponseSM for SendMessage, or the typed method returns error.
+	Interceptor func(ctx context.Context, action string, values url.Values, invoke Invoker) (Result, error)
+)
+
+type (
+	headerKey    struct{}
+	requestIdKey struct{}
+)
+
+// ClientRequestId get clientRequestId of the action, it's assigned before interceptors are invoked
+//  input: ctx context.Context passed to Interceptor
+//  return: uint64
+//  return: bool
+func ClientRequestId(ctx context.Context) (uint64, bool) {
+	id, ok := ctx.Value(requestIdKey{}).(uint64)
+	return id, ok
+}
+
+// WithHeader add http header into context for requests send with it, useful for header injection in Interceptor
+//  input: ctx context.Context
+//  input: key string
+//  input: value string
+//  return: context.Context
+func WithHeader(ctx context.Context, key, value string) context.Context {
+	header := map[string]string{}
+	if h, ok := ctx.Value(headerKey{}).(map[string]string); ok {
+		for k, v := range h {
+			header[k] = v
+		}
+	}
+	header[key] = value
+	return context.WithValue(ctx, headerKey{}, header)
+}
+
+// headerFrom get http header added by WithHeader
+//  input: ctx context.Context
+//  return: map[string]string
+func headerFrom(ctx context.Context) map[string]string {
+	h, _ := ctx.Value(headerKey{}).(map[string]string)
+	return h
+}
+
+// chain compose interceptors in order, the first one is the outermost
+//  input: interceptors []Interceptor
+//  input: invoke Invoker the innermost invoker
+//  return: Invoker
+func chain(interceptors []Interceptor, invoke Invoker) Invoker {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		interceptor, next := interceptors[i], invoke
+		invoke = func(ctx context.Context, action string, values url.Values) (Result, error) {
+			return interceptor(ctx, action, values, next)
+		}
+	}
+	return invoke
+}
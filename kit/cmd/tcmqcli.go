@@ -397,14 +397,18 @@ func main() {
 				uri = fmt.Sprintf(wanUrl, region)
 			}
 		}
-		tcmq.InsecureSkipVerify = insecure
-		client, err = tcmq.NewClient(uri, sid, key, time.Duration(timeout)*time.Second, keepalive)
+		client, err = tcmq.NewClientWithOptions(uri,
+			tcmq.WithSecret(sid, key),
+			tcmq.WithToken(token),
+			tcmq.WithMethod(method),
+			tcmq.WithTimeout(time.Duration(timeout)*time.Second),
+			tcmq.WithKeepAlive(keepalive),
+			tcmq.WithInsecureSkipVerify(insecure),
+		)
 		if err != nil {
 			log.Println("new TCMQ client", err)
 			return
 		}
-		client.Method = method
-		client.Token = token
 		client.Debug = debug
 	case `create`, `remove`, `modify`, `describe`, `list`, `c`, `e`, `m`, `i`, `l`:
 		// 管控API文档: https://cloud.tencent.com/document/product/1496/62819
@@ -515,8 +519,8 @@ func send() {
 		}
 	case length > 0:
 		msg := strings.Repeat(`#`, length)
-		if length > tcmq.MaxMessageSize {
-			tcmq.MaxMessageSize = length
+		if length > client.Limits.MaxMessageSize {
+			client.Limits.MaxMessageSize = length
 		}
 		if number > 1 {
 			msgs = make([]string, 0, number)
@@ -643,8 +647,8 @@ func publish() {
 		}
 	case length > 0:
 		msg := strings.Repeat(`#`, length)
-		if length > tcmq.MaxMessageSize {
-			tcmq.MaxMessageSize = length
+		if length > client.Limits.MaxMessageSize {
+			client.Limits.MaxMessageSize = length
 		}
 		if number > 1 {
 			msgs = make([]string, 0, number)
@@ -0,0 +1,179 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var ErrRateLimited = errors.New("rate limited")
+
+// Limit of token bucket
+type Limit struct {
+	Rate  float64 // requests per second, <=0 means unlimited
+	Burst int     // max requests at once, default: 1
+}
+
+// RateLimiter limit requests of client globally, per action and per queue/topic name with token buckets
+//  the request waits until all matched limits allow it, or fails fast with ErrRateLimited,
+//  buckets refilled to Burst are removed when there are many of them, they're the same as new ones.
+type RateLimiter struct {
+	Global    Limit            // all requests of the client
+	Actions   map[string]Limit // key: action, ex: SendMessage
+	Resources map[string]Limit // key: queue/topic name
+	Resource  Limit            // each queue/topic not in Resources
+	FailFast  bool             // return ErrRateLimited instead of waiting
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweepAt int // number of buckets to remove full ones
+}
+
+// minSweep number of buckets to start removing full ones
+const minSweep = 1024
+
+type bucket struct {
+	Limit
+	tokens float64
+	last   time.Time
+}
+
+// burst max tokens of bucket
+//  return: float64
+func (b *bucket) burst() float64 {
+	if b.Burst < 1 {
+		return 1
+	}
+	return float64(b.Burst)
+}
+
+// full check whether bucket is refilled to burst at now
+//  input: now time.Time
+//  return: bool
+func (b *bucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.Rate >= b.burst()
+}
+
+// take n token from bucket, the tokens could be negative as reservation
+//  input: now time.Time
+//  input: n float64
+//  return: time.Duration wait time until the tokens are available
+func (b *bucket) take(now time.Time, n float64) time.Duration {
+	burst := b.burst()
+	if b.last.IsZero() {
+		b.tokens = burst
+	} else if b.tokens += now.Sub(b.last).Seconds() * b.Rate; b.tokens > burst {
+		b.tokens = burst
+	}
+	b.last = now
+	b.tokens -= n
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.Rate * float64(time.Second))
+}
+
+// buckets matched by action and resource
+//  input: now time.Time
+//  input: action string
+//  input: resource string
+//  return: []*bucket
+func (l *RateLimiter) match(now time.Time, action, resource string) []*bucket {
+	if l.buckets == nil {
+		l.buckets = map[string]*bucket{}
+	}
+	if len(l.buckets) >= l.sweepAt {
+		l.sweep(now)
+	}
+	var list []*bucket
+	add := func(key string, limit Limit) {
+		if limit.Rate <= 0 {
+			return
+		}
+		b, ok := l.buckets[key]
+		if !ok {
+			b = &bucket{Limit: limit}
+			l.buckets[key] = b
+		}
+		list = append(list, b)
+	}
+	add(``, l.Global)
+	add(`action:`+action, l.Actions[action])
+	if resource != `` {
+		if limit, ok := l.Resources[resource]; ok {
+			add(`resource:`+resource, limit)
+		} else {
+			add(`resource:`+resource, l.Resource)
+		}
+	}
+	return list
+}
+
+// sweep remove full buckets, check again when the number of buckets doubled
+//  input: now time.Time
+func (l *RateLimiter) sweep(now time.Time) {
+	for key, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, key)
+		}
+	}
+	l.sweepAt = 2 * len(l.buckets)
+	if l.sweepAt < minSweep {
+		l.sweepAt = minSweep
+	}
+}
+
+// wait until request is allowed by all matched limits
+//  input: ctx context.Context
+//  input: clock Clock time of token buckets
+//  input: values url.Values
+//  return: error
+func (l *RateLimiter) wait(ctx context.Context, clock Clock, values url.Values) error {
+	if l == nil {
+		return nil
+	}
+	action := values.Get(`Action`)
+	resource := values.Get(`queueName`)
+	if resource == `` {
+		resource = values.Get(`topicName`)
+	}
+
+	l.mu.Lock()
+	now := clock.Now()
+	buckets := l.match(now, action, resource)
+	var delay time.Duration
+	for _, b := range buckets {
+		if d := b.take(now, 1); d > delay {
+			delay = d
+		}
+	}
+	if delay > 0 && l.FailFast {
+		for _, b := range buckets {
+			b.tokens++ // give back
+		}
+	}
+	l.mu.Unlock()
+
+	switch {
+	case delay == 0:
+		return nil
+	case l.FailFast:
+		return fmt.Errorf("%w %s %s", ErrRateLimited, action, resource)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		l.mu.Lock()
+		for _, b := range buckets {
+			b.tokens++ // cancel reservation
+		}
+		l.mu.Unlock()
+		return fmt.Errorf("wait rate limit %s: %w", action, ctx.Err())
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,128 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stepClock Clock moved forward by test
+type stepClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *stepClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *stepClock) add(d time.Duration) {
+	c.mu.Lock()
+	c.t = c.t.Add(d)
+	c.mu.Unlock()
+}
+
+func request(action, queue string) url.Values {
+	return url.Values{`Action`: {action}, `queueName`: {queue}}
+}
+
+// TestRateLimiterFailFast global, per action and per resource buckets refilled by Clock
+func TestRateLimiterFailFast(t *testing.T) {
+	clock := &stepClock{t: time.Unix(1700000000, 0)}
+	l := &RateLimiter{
+		Global:    Limit{Rate: 1, Burst: 4},
+		Actions:   map[string]Limit{actionSendMsg: {Rate: 1, Burst: 1}},
+		Resources: map[string]Limit{`a`: {Rate: 1, Burst: 1}},
+		Resource:  Limit{Rate: 1, Burst: 2},
+		FailFast:  true,
+	}
+	ctx := context.Background()
+	steps := []struct {
+		values url.Values
+		limit  bool
+	}{
+		{request(actionSendMsg, `b`), false},
+		{request(actionSendMsg, `b`), true}, // action
+		{request(actionRecvMsg, `b`), false},
+		{request(actionRecvMsg, `b`), true}, // resource
+		{request(actionRecvMsg, `a`), false},
+		{request(actionRecvMsg, `a`), true}, // resource in Resources
+		{request(actionRecvMsg, `c`), false},
+		{request(actionRecvMsg, `c`), true}, // global
+	}
+	for i, s := range steps {
+		if err := l.wait(ctx, clock, s.values); errors.Is(err, ErrRateLimited) != s.limit {
+			t.Fatalf("step %d %v: %v", i, s.values, err)
+		}
+	}
+	clock.add(time.Second)
+	if err := l.wait(ctx, clock, request(actionSendMsg, `b`)); err != nil {
+		t.Fatalf("tokens not refilled by clock: %v", err)
+	}
+}
+
+// TestRateLimiterWait blocking until token is available, reservation is given back on cancel
+func TestRateLimiterWait(t *testing.T) {
+	clock := &stepClock{t: time.Unix(1700000000, 0)}
+	l := &RateLimiter{Global: Limit{Rate: 50, Burst: 1}}
+	ctx := context.Background()
+	if err := l.wait(ctx, clock, request(actionSendMsg, `q`)); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := l.wait(ctx, clock, request(actionSendMsg, `q`)); err != nil {
+		t.Fatal(err)
+	}
+	if d := time.Since(start); d < 15*time.Millisecond {
+		t.Errorf("waited %s, expect 20ms", d)
+	}
+
+	l = &RateLimiter{Global: Limit{Rate: 1, Burst: 1}}
+	if err := l.wait(ctx, clock, request(actionSendMsg, `q`)); err != nil {
+		t.Fatal(err)
+	}
+	cctx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if err := l.wait(cctx, clock, request(actionSendMsg, `q`)); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("cancelled wait: %v", err)
+	}
+	clock.add(time.Second)
+	l.FailFast = true
+	if err := l.wait(ctx, clock, request(actionSendMsg, `q`)); err != nil {
+		t.Fatalf("reservation not given back: %v", err)
+	}
+}
+
+// TestRateLimiterSweep full buckets of idle resources are removed
+func TestRateLimiterSweep(t *testing.T) {
+	clock := &stepClock{t: time.Unix(1700000000, 0)}
+	l := &RateLimiter{Resource: Limit{Rate: 1, Burst: 1}, FailFast: true}
+	ctx := context.Background()
+	for i := 0; i < 4*minSweep; i++ {
+		if err := l.wait(ctx, clock, request(actionSendMsg, strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := len(l.buckets); n != 4*minSweep {
+		t.Fatalf("%d buckets, busy ones are kept", n)
+	}
+	clock.add(time.Second)
+	if err := l.wait(ctx, clock, request(actionSendMsg, `active`)); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < minSweep; i++ {
+		_ = l.wait(ctx, clock, request(actionSendMsg, `new`+strconv.Itoa(i)))
+	}
+	if n := len(l.buckets); n > 2*minSweep {
+		t.Errorf("%d buckets after idle ones refilled", n)
+	}
+	if err := l.wait(ctx, clock, request(actionSendMsg, `active`)); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("active bucket removed: %v", err)
+	}
+}
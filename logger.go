@@ -0,0 +1,189 @@
+package tdmq
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LogLevel severity of log, same values as log/slog
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = -4
+	LevelInfo  LogLevel = 0
+	LevelWarn  LogLevel = 4
+	LevelError LogLevel = 8
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return `DEBUG`
+	case LevelInfo:
+		return `INFO`
+	case LevelWarn:
+		return `WARN`
+	case LevelError:
+		return `ERROR`
+	}
+	return `LEVEL(` + strconv.Itoa(int(l)) + `)`
+}
+
+// Logger structured logger, args are alternating key/value pairs like log/slog
+type Logger interface {
+	Log(ctx context.Context, level LogLevel, msg string, args ...any)
+}
+
+// LogConfig logging of client requests, credentials are always redacted
+type LogConfig struct {
+	Logger     Logger
+	Level      LogLevel // minimum level to log
+	Actions    []string // only log these actions, empty means all actions
+	SampleRate float64  // ratio of requests to log in (0,1], <=0 means log all requests
+	RedactBody bool     // redact message body in request and response
+}
+
+// RedactKeys request parameters with credential, always redacted in log
+var RedactKeys = []string{`SecretId`, `Signature`, `Token`}
+
+var (
+	debugLog = &LogConfig{Logger: NewTextLogger(os.Stdout), Level: LevelDebug}
+
+	bodyReg = regexp.MustCompile(`"msgBody"\s*:\s*"(?:[^"\\]|\\.)*"`)
+)
+
+type textLogger struct {
+	*log.Logger
+}
+
+// NewTextLogger create Logger write lines of "time LEVEL msg key=value ..." to w
+//  input: w io.Writer
+//  return: Logger
+func NewTextLogger(w io.Writer) Logger {
+	return &textLogger{Logger: log.New(w, ``, log.LstdFlags|log.Lmicroseconds)}
+}
+
+func (t *textLogger) Log(_ context.Context, level LogLevel, msg string, args ...any) {
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		b.WriteByte(' ')
+		if i+1 < len(args) {
+			b.WriteString(fmt.Sprint(args[i]))
+			b.WriteByte('=')
+			b.WriteString(strconv.Quote(fmt.Sprint(args[i+1])))
+		} else {
+			b.WriteString(`!BADKEY=`)
+			b.WriteString(strconv.Quote(fmt.Sprint(args[i])))
+		}
+	}
+	t.Println(b.String())
+}
+
+// requestLog logger of single request, nil means discard
+type requestLog struct {
+	*LogConfig
+	action string
+	id     uint64
+}
+
+// requestLog get logger for request of action, nil when logging is disabled, filtered or not sampled
+//  input: action string
+//  input: id uint64 clientRequestId
+//  return: *requestLog
+func (c *Client) requestLog(action string, id uint64) *requestLog {
+	cfg := c.Log
+	if cfg == nil && c.Debug {
+		cfg = debugLog
+	}
+	if cfg == nil || cfg.Logger == nil {
+		return nil
+	}
+	if len(cfg.Actions) > 0 {
+		var found bool
+		for _, a := range cfg.Actions {
+			if a == action {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil
+		}
+	}
+	if cfg.SampleRate > 0 && cfg.SampleRate < 1 && c.rand().Float64() >= cfg.SampleRate {
+		return nil
+	}
+	return &requestLog{LogConfig: cfg, action: action, id: id}
+}
+
+// log message with args if level is enabled
+//  input: ctx context.Context
+//  input: level LogLevel
+//  input: msg string
+//  input: args ...any
+func (l *requestLog) log(ctx context.Context, level LogLevel, msg string, args ...any) {
+	if l == nil || level < l.Level {
+		return
+	}
+	l.Logger.Log(ctx, level, msg, append([]any{`action`, l.action, `clientRequestId`, l.id}, args...)...)
+}
+
+// enabled check whether level is enabled, avoid building args of discarded log
+//  input: level LogLevel
+//  return: bool
+func (l *requestLog) enabled(level LogLevel) bool {
+	return l != nil && level >= l.Level
+}
+
+// values copy request values with credentials and (optionally) message bodies redacted
+//  input: values url.Values
+//  return: url.Values
+func (l *requestLog) values(values url.Values) url.Values {
+	redacted := make(url.Values, len(values))
+	for k, v := range values {
+		redacted[k] = v
+	}
+	for _, k := range RedactKeys {
+		if v := redacted.Get(k); v != `` {
+			redacted.Set(k, redact(v))
+		}
+	}
+	if l.RedactBody {
+		for k, v := range redacted {
+			if k == `msgBody` || strings.HasPrefix(k, `msgBody.`) {
+				redacted.Set(k, `***(`+strconv.Itoa(len(strings.Join(v, ``)))+` bytes)`)
+			}
+		}
+	}
+	return redacted
+}
+
+// body redact message bodies in response if configured
+//  input: raw string
+//  return: string
+func (l *requestLog) body(raw string) string {
+	if !l.RedactBody {
+		return raw
+	}
+	return bodyReg.ReplaceAllString(raw, `"msgBody":"***"`)
+}
+
+// redact keep a short prefix of credential for troubleshooting
+//  input: s string
+//  return: string
+func redact(s string) string {
+	if len(s) > 8 {
+		return s[:4] + `***`
+	}
+	return `***`
+}
@@ -0,0 +1,86 @@
+package tdmq
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestLogRedact credentials never logged in full, message bodies masked by RedactBody
+func TestLogRedact(t *testing.T) {
+	var mu sync.Mutex
+	var signatures []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		mu.Lock()
+		signatures = append(signatures, r.Form.Get(`Signature`))
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":0,"msgId":"m","msgBody":"secret reply","receiptHandle":"h"}`))
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDsecretid123456`, `secretkey`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Token = `tokentokentoken123456`
+	var buf bytes.Buffer
+	c.Log = &LogConfig{Logger: NewTextLogger(&buf), Level: LevelDebug, RedactBody: true}
+
+	if _, err = c.SendMessage(`queue`, `secret message`, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.BatchSendMessage(`queue`, []string{`secret batch0`, `secret batch1`}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = c.ReceiveMessage(`queue`, 0); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if out == `` {
+		t.Fatal("nothing logged")
+	}
+	for _, s := range append(signatures, c.SecretId, c.Token, `secret message`, `secret batch`, `secret reply`) {
+		if strings.Contains(out, s) || strings.Contains(out, url.QueryEscape(s)) {
+			t.Errorf("%q in log:\n%s", s, out)
+		}
+	}
+	for _, s := range []string{`SecretId=AKID***`, `Token=toke***`, `msgBody=***(14 bytes)`, `msgBody.1=***(13 bytes)`, `\"msgBody\":\"***\"`} {
+		if !strings.Contains(out, s) {
+			t.Errorf("%q not in log:\n%s", s, out)
+		}
+	}
+}
+
+// TestLogFilter Actions and SampleRate of LogConfig
+func TestLogFilter(t *testing.T) {
+	c := &Client{Rand: NewRand(1), Log: &LogConfig{Logger: NewTextLogger(&bytes.Buffer{}), Actions: []string{actionSendMsg}}}
+	if c.requestLog(actionSendMsg, 1) == nil || c.requestLog(actionRecvMsg, 1) != nil {
+		t.Error("log filtered by Actions")
+	}
+
+	c.Log.Actions = nil
+	c.Log.SampleRate = 0.25
+	var n int
+	for i := 0; i < 10000; i++ {
+		if c.requestLog(actionSendMsg, uint64(i)) != nil {
+			n++
+		}
+	}
+	if n < 2000 || n > 3000 {
+		t.Errorf("sampled %d of 10000 requests with rate 0.25", n)
+	}
+
+	c.Log.SampleRate = 0
+	if c.requestLog(actionSendMsg, 1) == nil {
+		t.Error("SampleRate 0 logs all requests")
+	}
+	c.Log = nil
+	if c.requestLog(actionSendMsg, 1) != nil {
+		t.Error("nil LogConfig discards logs")
+	}
+}
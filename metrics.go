@@ -0,0 +1,225 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Observation result of an action
+type Observation struct {
+	Action   string
+	Resource string // queue/topic name
+	Status   int    // HTTP Response status code, 0 when no response
+	Code     int    // CMQ response code
+	Err      error
+	Latency  time.Duration // include retries
+}
+
+// Metrics observe each action, must be safe for concurrent use
+type Metrics interface {
+	Observe(o Observation)
+}
+
+// DefaultBuckets latency histogram buckets in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30}
+
+// observing interceptor observe each action
+//  input: metrics Metrics
+//  return: Interceptor
+func observing(metrics Metrics) Interceptor {
+	return func(ctx context.Context, action string, values url.Values, invoke Invoker) (Result, error) {
+		start := time.Now()
+		res, err := invoke(ctx, action, values)
+		o := Observation{
+			Action:   action,
+			Resource: values.Get(`queueName`),
+			Err:      err,
+			Latency:  time.Since(start),
+		}
+		if o.Resource == `` {
+			o.Resource = values.Get(`topicName`)
+		}
+		var he *HTTPError
+		if msg, ok := res.(*msgResponse); ok && msg != nil {
+			o.Status, o.Code = msg.Status, msg.Code_
+		} else if errors.As(err, &he) {
+			o.Status = he.Status // result is dropped on error
+		}
+		metrics.Observe(o)
+		return res, err
+	}
+}
+
+// Collector Metrics aggregate request counters and latency histograms in memory,
+//  expose them by Publish (expvar) or Handler (Prometheus text format).
+//  requests and latency are labeled by action, resource (queue/topic name) and code,
+//  code label is CMQ response code, http_<status> for failed response without code, error for transport error.
+type Collector struct {
+	Buckets []float64 // sorted latency buckets in seconds, default: DefaultBuckets, do not change after use
+
+	mu       sync.Mutex
+	requests map[requestKey]uint64
+	latency  map[requestKey]*histogram
+}
+
+type requestKey struct {
+	action   string
+	resource string
+	code     string
+}
+
+type histogram struct {
+	counts []uint64 // count of each bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+func (c *Collector) Observe(o Observation) {
+	code := strconv.Itoa(o.Code)
+	switch {
+	case o.Err == nil || o.Code != CodeSuccess:
+	case o.Status != 0:
+		code = `http_` + strconv.Itoa(o.Status)
+	default:
+		code = `error`
+	}
+	buckets := c.buckets()
+	seconds := o.Latency.Seconds()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.requests == nil {
+		c.requests = map[requestKey]uint64{}
+		c.latency = map[requestKey]*histogram{}
+	}
+	key := requestKey{action: o.Action, resource: o.Resource, code: code}
+	c.requests[key]++
+	h, ok := c.latency[key]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(buckets))}
+		c.latency[key] = h
+	}
+	if i := sort.SearchFloat64s(buckets, seconds); i < len(buckets) {
+		h.counts[i]++
+	}
+	h.sum += seconds
+	h.count++
+}
+
+func (c *Collector) buckets() []float64 {
+	if c.Buckets != nil {
+		return c.Buckets
+	}
+	return DefaultBuckets
+}
+
+// Publish expose metrics as expvar with name, it panics if the name is already registered
+//  input: name string
+func (c *Collector) Publish(name string) {
+	expvar.Publish(name, expvar.Func(c.snapshot))
+}
+
+// snapshot metrics for expvar
+//  return: any
+func (c *Collector) snapshot() any {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	requests := map[string]map[string]map[string]uint64{} // action -> resource -> code -> count
+	for k, v := range c.requests {
+		if requests[k.action] == nil {
+			requests[k.action] = map[string]map[string]uint64{}
+		}
+		if requests[k.action][k.resource] == nil {
+			requests[k.action][k.resource] = map[string]uint64{}
+		}
+		requests[k.action][k.resource][k.code] = v
+	}
+	latency := map[string]map[string]map[string]any{} // action -> resource -> code -> histogram
+	for k, h := range c.latency {
+		buckets := map[string]uint64{}
+		var cumulative uint64
+		for i, le := range c.buckets() {
+			cumulative += h.counts[i]
+			buckets[strconv.FormatFloat(le, 'g', -1, 64)] = cumulative
+		}
+		if latency[k.action] == nil {
+			latency[k.action] = map[string]map[string]any{}
+		}
+		if latency[k.action][k.resource] == nil {
+			latency[k.action][k.resource] = map[string]any{}
+		}
+		latency[k.action][k.resource][k.code] = map[string]any{`buckets`: buckets, `sum`: h.sum, `count`: h.count}
+	}
+	return map[string]any{`requests`: requests, `latency_seconds`: latency}
+}
+
+// Handler expose metrics in Prometheus text format
+//  return: http.Handler
+func (c *Collector) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set(`Content-Type`, `text/plain; version=0.0.4; charset=utf-8`)
+		_ = c.WritePrometheus(w)
+	})
+}
+
+// WritePrometheus write metrics in Prometheus text format
+//  input: w io.Writer
+//  return: error
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	c.mu.Lock()
+	keys := make([]requestKey, 0, len(c.requests))
+	for k := range c.requests {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.action != b.action {
+			return a.action < b.action
+		}
+		if a.resource != b.resource {
+			return a.resource < b.resource
+		}
+		return a.code < b.code
+	})
+
+	var b strings.Builder
+	b.WriteString("# HELP cmq_requests_total Requests of CMQ actions.\n# TYPE cmq_requests_total counter\n")
+	labels := make([]string, len(keys))
+	for i, k := range keys {
+		labels[i] = `action="` + escapeLabel(k.action) + `",resource="` + escapeLabel(k.resource) + `",code="` + escapeLabel(k.code) + `"`
+		fmt.Fprintf(&b, "cmq_requests_total{%s} %d\n", labels[i], c.requests[k])
+	}
+	b.WriteString("# HELP cmq_request_duration_seconds Latency of CMQ actions include retries.\n# TYPE cmq_request_duration_seconds histogram\n")
+	for i, k := range keys {
+		h, label := c.latency[k], labels[i]
+		var cumulative uint64
+		for j, le := range c.buckets() {
+			cumulative += h.counts[j]
+			fmt.Fprintf(&b, "cmq_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", label, strconv.FormatFloat(le, 'g', -1, 64), cumulative)
+		}
+		fmt.Fprintf(&b, "cmq_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", label, h.count)
+		fmt.Fprintf(&b, "cmq_request_duration_seconds_sum{%s} %s\n", label, strconv.FormatFloat(h.sum, 'g', -1, 64))
+		fmt.Fprintf(&b, "cmq_request_duration_seconds_count{%s} %d\n", label, h.count)
+	}
+	c.mu.Unlock()
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escape label value of Prometheus text format
+//  input: s string
+//  return: string
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
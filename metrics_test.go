@@ -0,0 +1,123 @@
+package tdmq
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// observations Metrics record observations in memory
+type observations struct {
+	mu   sync.Mutex
+	list []Observation
+}
+
+func (m *observations) Observe(o Observation) {
+	m.mu.Lock()
+	m.list = append(m.list, o)
+	m.mu.Unlock()
+}
+
+// TestObserveHTTPError status of failed response is observed and traced
+func TestObserveHTTPError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `bad gateway`, http.StatusBadGateway)
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics, recorder, collector := &observations{}, &SpanRecorder{}, &Collector{}
+	c.Metrics, c.Tracer = metrics, recorder
+	if _, err = c.SendMessage(`queue`, `message`, 0); err == nil {
+		t.Fatal("expect error of status 502")
+	}
+	if len(metrics.list) != 1 || metrics.list[0].Status != http.StatusBadGateway {
+		t.Fatalf("observations: %+v", metrics.list)
+	}
+	if spans := recorder.Spans(); len(spans) != 1 || spans[0].Attributes[AttrStatus] != http.StatusBadGateway {
+		t.Fatalf("spans: %+v", spans)
+	}
+	collector.Observe(metrics.list[0])
+	if n := collector.requests[requestKey{action: actionSendMsg, resource: `queue`, code: `http_502`}]; n != 1 {
+		t.Errorf("requests: %v", collector.requests)
+	}
+}
+
+// TestWritePrometheus golden text of counters and histograms labeled by action, resource and code
+func TestWritePrometheus(t *testing.T) {
+	c := &Collector{Buckets: []float64{.01, .1, 1}}
+	c.Observe(Observation{Action: actionSendMsg, Resource: `a`, Status: 200, Latency: 50 * time.Millisecond})
+	c.Observe(Observation{Action: actionSendMsg, Resource: `a`, Status: 200, Latency: 2 * time.Second})
+	c.Observe(Observation{Action: actionRecvMsg, Resource: `b"x`, Status: 200, Code: CodeNoMessage, Latency: 5 * time.Millisecond})
+	c.Observe(Observation{Action: actionPubMsg, Resource: `t`, Err: errors.New("refused"), Latency: 20 * time.Millisecond})
+	var b strings.Builder
+	if err := c.WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	expect := `# HELP cmq_requests_total Requests of CMQ actions.
+# TYPE cmq_requests_total counter
+cmq_requests_total{action="PublishMessage",resource="t",code="error"} 1
+cmq_requests_total{action="ReceiveMessage",resource="b\"x",code="7000"} 1
+cmq_requests_total{action="SendMessage",resource="a",code="0"} 2
+# HELP cmq_request_duration_seconds Latency of CMQ actions include retries.
+# TYPE cmq_request_duration_seconds histogram
+cmq_request_duration_seconds_bucket{action="PublishMessage",resource="t",code="error",le="0.01"} 0
+cmq_request_duration_seconds_bucket{action="PublishMessage",resource="t",code="error",le="0.1"} 1
+cmq_request_duration_seconds_bucket{action="PublishMessage",resource="t",code="error",le="1"} 1
+cmq_request_duration_seconds_bucket{action="PublishMessage",resource="t",code="error",le="+Inf"} 1
+cmq_request_duration_seconds_sum{action="PublishMessage",resource="t",code="error"} 0.02
+cmq_request_duration_seconds_count{action="PublishMessage",resource="t",code="error"} 1
+cmq_request_duration_seconds_bucket{action="ReceiveMessage",resource="b\"x",code="7000",le="0.01"} 1
+cmq_request_duration_seconds_bucket{action="ReceiveMessage",resource="b\"x",code="7000",le="0.1"} 1
+cmq_request_duration_seconds_bucket{action="ReceiveMessage",resource="b\"x",code="7000",le="1"} 1
+cmq_request_duration_seconds_bucket{action="ReceiveMessage",resource="b\"x",code="7000",le="+Inf"} 1
+cmq_request_duration_seconds_sum{action="ReceiveMessage",resource="b\"x",code="7000"} 0.005
+cmq_request_duration_seconds_count{action="ReceiveMessage",resource="b\"x",code="7000"} 1
+cmq_request_duration_seconds_bucket{action="SendMessage",resource="a",code="0",le="0.01"} 0
+cmq_request_duration_seconds_bucket{action="SendMessage",resource="a",code="0",le="0.1"} 1
+cmq_request_duration_seconds_bucket{action="SendMessage",resource="a",code="0",le="1"} 1
+cmq_request_duration_seconds_bucket{action="SendMessage",resource="a",code="0",le="+Inf"} 2
+cmq_request_duration_seconds_sum{action="SendMessage",resource="a",code="0"} 2.05
+cmq_request_duration_seconds_count{action="SendMessage",resource="a",code="0"} 2
+`
+	if got := b.String(); got != expect {
+		t.Errorf("prometheus text:\n%s\nexpect:\n%s", got, expect)
+	}
+}
+
+// TestPublish expvar snapshot of requests and latency
+func TestPublish(t *testing.T) {
+	c := &Collector{Buckets: []float64{.1, 1}}
+	c.Observe(Observation{Action: actionSendMsg, Resource: `a`, Status: 200, Latency: 50 * time.Millisecond})
+	c.Observe(Observation{Action: actionSendMsg, Resource: `a`, Status: 502, Err: errors.New("bad gateway"), Latency: 2 * time.Second})
+	c.Publish(`cmq_test_publish`)
+	var snapshot struct {
+		Requests map[string]map[string]map[string]uint64 `json:"requests"`
+		Latency  map[string]map[string]map[string]struct {
+			Buckets map[string]uint64 `json:"buckets"`
+			Sum     float64           `json:"sum"`
+			Count   uint64            `json:"count"`
+		} `json:"latency_seconds"`
+	}
+	if err := json.Unmarshal([]byte(expvar.Get(`cmq_test_publish`).String()), &snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if r := snapshot.Requests[actionSendMsg][`a`]; r[`0`] != 1 || r[`http_502`] != 1 {
+		t.Errorf("requests: %v", snapshot.Requests)
+	}
+	h := snapshot.Latency[actionSendMsg][`a`][`0`]
+	if h.Count != 1 || h.Sum != .05 || h.Buckets[`0.1`] != 1 || h.Buckets[`1`] != 1 {
+		t.Errorf("latency: %+v", snapshot.Latency)
+	}
+	if h = snapshot.Latency[actionSendMsg][`a`][`http_502`]; h.Count != 1 || h.Buckets[`1`] != 0 {
+		t.Errorf("latency: %+v", snapshot.Latency)
+	}
+}
@@ -0,0 +1,283 @@
+package tdmq
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Option configure client created by NewClientWithOptions
+type Option func(c *Client, o *options) error
+
+// options of http client, applied after all Option
+type options struct {
+	tls       *tls.Config
+	keepalive bool
+	transport http.RoundTripper
+}
+
+// NewClientWithOptions create TDMQ CMQ client with options, settings are isolated from other clients
+//  input: endpoint string request uri for TDMQ CMQ service
+//  input: opts ...Option
+//  return: *Client
+//  return: error
+func NewClientWithOptions(endpoint string, opts ...Option) (*Client, error) {
+	limits := DefaultLimits()
+	c := &Client{
+		Method:     http.MethodPost,
+		SignMethod: HmacSHA1,
+		Limits:     &limits,
+	}
+	var err error
+	c.Url, err = url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	if len(c.Url.Path) == 0 {
+		c.Url.Path = `/`
+	}
+
+	o := &options{tls: &tls.Config{}, keepalive: true}
+	for _, opt := range opts {
+		if err = opt(c, o); err != nil {
+			return nil, err
+		}
+	}
+	if c.Rand == nil {
+		c.Rand = NewRand(0)
+	}
+	c.id = uint64(c.Rand.Uint32())
+	if c.HttpClient == nil {
+		transport := o.transport
+		if transport == nil {
+			transport = &http.Transport{
+				DisableKeepAlives: !o.keepalive,
+				TLSClientConfig:   o.tls,
+			}
+		}
+		c.HttpClient = &http.Client{Transport: transport}
+	}
+	return c, nil
+}
+
+// WithSecret sign request with secret id and key
+//  input: secretId string
+//  input: secretKey string
+//  return: Option
+func WithSecret(secretId, secretKey string) Option {
+	return func(c *Client, _ *options) error {
+		c.SecretId, c.SecretKey = secretId, secretKey
+		return nil
+	}
+}
+
+// WithToken token of temporary secret
+//  input: token string
+//  return: Option
+func WithToken(token string) Option {
+	return func(c *Client, _ *options) error {
+		c.Token = token
+		return nil
+	}
+}
+
+// WithCredential sign request with credential from provider
+//  input: provider CredentialProvider
+//  return: Option
+func WithCredential(provider CredentialProvider) Option {
+	return func(c *Client, _ *options) error {
+		c.Credential = provider
+		return nil
+	}
+}
+
+// WithAppId request without authentication for privatization
+//  input: appId uint64
+//  return: Option
+func WithAppId(appId uint64) Option {
+	return func(c *Client, _ *options) error {
+		c.AppId = appId
+		return nil
+	}
+}
+
+// WithAuth authenticate request by custom scheme, ex: tcmq.BearerAuth, tcmq.NoAuth
+//  input: auth Authenticator
+//  return: Option
+func WithAuth(auth Authenticator) Option {
+	return func(c *Client, _ *options) error {
+		c.Auth = auth
+		return nil
+	}
+}
+
+// WithMethod http request method
+//  input: method string GET, POST, AUTO
+//  return: Option
+func WithMethod(method string) Option {
+	return func(c *Client, _ *options) error {
+		switch method {
+		case http.MethodGet, http.MethodPost, MethodAuto:
+			c.Method = method
+			return nil
+		}
+		return errors.New("unsupported request method: " + method)
+	}
+}
+
+// WithSignMethod signature method
+//  input: method string HmacSHA1, HmacSHA256, TC3-HMAC-SHA256
+//  return: Option
+func WithSignMethod(method string) Option {
+	return func(c *Client, _ *options) error {
+		switch method {
+		case HmacSHA1, HmacSHA256, TC3HmacSHA256:
+			c.SignMethod = method
+			return nil
+		}
+		return errors.New("unsupported sign method: " + method)
+	}
+}
+
+// WithHeaders http header for each request
+//  input: header map[string]string
+//  return: Option
+func WithHeaders(header map[string]string) Option {
+	return func(c *Client, _ *options) error {
+		if c.Header == nil {
+			c.Header = make(map[string]string, len(header))
+		}
+		for k, v := range header {
+			c.Header[k] = v
+		}
+		return nil
+	}
+}
+
+// WithTimeout per request timeout
+//  input: t time.Duration
+//  return: Option
+func WithTimeout(t time.Duration) Option {
+	return func(c *Client, _ *options) error {
+		c.Timeout = t
+		return nil
+	}
+}
+
+// WithLimits limits of request parameters instead of package level Max* variables, zero fields are the package level ones
+//  input: limits Limits
+//  return: Option
+func WithLimits(limits Limits) Option {
+	return func(c *Client, _ *options) error {
+		limits = limits.withDefaults()
+		c.Limits = &limits
+		return nil
+	}
+}
+
+// WithKeepAlive http connection keep alive to server, default: true
+//  input: keepalive bool
+//  return: Option
+func WithKeepAlive(keepalive bool) Option {
+	return func(_ *Client, o *options) error {
+		o.keepalive = keepalive
+		return nil
+	}
+}
+
+// WithTLSConfig tls config of http transport, it's cloned and could be changed by following tls options
+//  input: config *tls.Config nil means default config
+//  return: Option
+func WithTLSConfig(config *tls.Config) Option {
+	return func(_ *Client, o *options) error {
+		if config == nil {
+			config = &tls.Config{}
+		}
+		o.tls = config.Clone()
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify skip verify server certificate
+//  input: skip bool
+//  return: Option
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(_ *Client, o *options) error {
+		o.tls.InsecureSkipVerify = skip
+		return nil
+	}
+}
+
+// WithCACert verify server certificate with CA instead of system roots
+//  input: pem []byte PEM encoded CA certificate(s)
+//  return: Option
+func WithCACert(pem []byte) Option {
+	return func(_ *Client, o *options) error {
+		if o.tls.RootCAs == nil {
+			o.tls.RootCAs = x509.NewCertPool()
+		}
+		if !o.tls.RootCAs.AppendCertsFromPEM(pem) {
+			return errors.New("no valid CA certificate")
+		}
+		return nil
+	}
+}
+
+// WithClientCert client certificate for mTLS
+//  input: certPEM []byte PEM encoded certificate
+//  input: keyPEM []byte PEM encoded private key
+//  return: Option
+func WithClientCert(certPEM, keyPEM []byte) Option {
+	return func(_ *Client, o *options) error {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("load client certificate: %w", err)
+		}
+		o.tls.Certificates = append(o.tls.Certificates, cert)
+		return nil
+	}
+}
+
+// WithTransport http transport, tls and keepalive options are ignored
+//  input: transport http.RoundTripper
+//  return: Option
+func WithTransport(transport http.RoundTripper) Option {
+	return func(_ *Client, o *options) error {
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithHttpClient http client, transport, tls and keepalive options are ignored
+//  input: client *http.Client
+//  return: Option
+func WithHttpClient(client *http.Client) Option {
+	return func(c *Client, _ *options) error {
+		c.HttpClient = client
+		return nil
+	}
+}
+
+// WithClock source of request Timestamp
+//  input: clock Clock
+//  return: Option
+func WithClock(clock Clock) Option {
+	return func(c *Client, _ *options) error {
+		c.Clock = clock
+		return nil
+	}
+}
+
+// WithRand source of Nonce, clientRequestId and jitter, ex: NewRand(1) for deterministic requests
+//  input: r Rand
+//  return: Option
+func WithRand(r Rand) Option {
+	return func(c *Client, _ *options) error {
+		c.Rand = r
+		return nil
+	}
+}
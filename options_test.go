@@ -0,0 +1,23 @@
+package tdmq
+
+import "testing"
+
+// TestWithLimitsDefaults zero fields of limits fall back to package level limits
+func TestWithLimitsDefaults(t *testing.T) {
+	c, err := NewClientWithOptions(`http://127.0.0.1:1`, WithLimits(Limits{MaxMessageSize: 2 << 20}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expect := DefaultLimits()
+	expect.MaxMessageSize = 2 << 20
+	if *c.Limits != expect {
+		t.Fatalf("limits %+v, expect %+v", *c.Limits, expect)
+	}
+}
+
+// TestWithTLSConfigNil following tls options work after nil config
+func TestWithTLSConfigNil(t *testing.T) {
+	if _, err := NewClientWithOptions(`https://127.0.0.1:1`, WithTLSConfig(nil), WithInsecureSkipVerify(true)); err != nil {
+		t.Fatal(err)
+	}
+}
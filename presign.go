@@ -0,0 +1,109 @@
+package tdmq
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Presign generate signed GET url of action, for scripts or tools without SecretKey until ttl expires.
+// Params are validated by the same rules of typed methods, ex: queueName, msgBody, delaySeconds for SendMessage.
+// The signed Expires param is only understood by Verifier, the url works behind a gateway verifying requests
+// with Verifier, which accepts it until Expires (at most Verifier.MaxTTL) and allows reuse before it expires.
+// The TDMQ gateway checks Timestamp in its own window and ignores Expires.
+// Only HmacSHA1/HmacSHA256 signature in params is supported, appId and header authentication are rejected.
+//  input: action string ex: SendMessage, ReceiveMessage
+//  input: params url.Values
+//  input: ttl time.Duration
+//  return: *url.URL
+//  return: error
+func (c *Client) Presign(action string, params url.Values, ttl time.Duration) (*url.URL, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("%w presign ttl(0<ttl): %s", ErrInvalidParameter, ttl)
+	}
+	if err := c.limits().validate(action, params); err != nil {
+		return nil, err
+	}
+	values := make(url.Values, len(params)+2)
+	for k, v := range params {
+		values[k] = v
+	}
+	values.Set(`Action`, action)
+	values.Set(`Expires`, strconv.FormatInt(c.now().Add(ttl).Unix(), 10))
+
+	ctx := context.Background()
+	base, _ := c.endpoint(ctx, values)
+	r, err := c.authRequest(ctx, values, atomic.AddUint64(&c.id, 1), http.MethodGet, base, nil)
+	if err != nil {
+		return nil, fmt.Errorf("presign %s: %w", action, err)
+	}
+	defer r.e.free()
+	if r.Get(`Signature`) == `` {
+		return nil, fmt.Errorf("presign %s: signature in params is required", action)
+	}
+	if err = c.checkURL(base, r.e.query); err != nil {
+		return nil, err
+	}
+	u := *base
+	u.RawQuery = string(r.e.query)
+	return &u, nil
+}
+
+// validate params of action by the rules of typed method
+//  input: action string
+//  input: params url.Values
+//  return: error
+func (l Limits) validate(action string, params url.Values) error {
+	ints := map[string]int{}
+	for _, k := range []string{`delaySeconds`, `pollingWaitSeconds`, `numOfMsg`} {
+		if v := params.Get(k); v != `` {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("%w %s: %s", ErrInvalidParameter, k, v)
+			}
+			ints[k] = n
+		}
+	}
+	queue, topic := params.Get(`queueName`), params.Get(`topicName`)
+	switch action {
+	case actionSendMsg:
+		return l.validateSend(queue, params.Get(`msgBody`), ints[`delaySeconds`])
+	case actionBatchSend:
+		return l.validateBatchSend(queue, indexed(params, `msgBody`), ints[`delaySeconds`])
+	case actionRecvMsg:
+		return l.validateReceive(queue, ints[`pollingWaitSeconds`])
+	case actionBatchRecv:
+		return l.validateBatchReceive(queue, ints[`pollingWaitSeconds`], ints[`numOfMsg`])
+	case actionDelMsg:
+		return l.validateDelete(queue, params.Get(`receiptHandle`))
+	case actionBatchDel:
+		return l.validateBatchDelete(queue, indexed(params, `receiptHandle`))
+	case actionPubMsg:
+		return l.validatePublish(topic, params.Get(`msgBody`), params.Get(`routingKey`), indexed(params, `msgTag`))
+	case actionBatchPub:
+		return l.validateBatchPublish(topic, params.Get(`routingKey`), indexed(params, `msgBody`), indexed(params, `msgTag`))
+	case actionQueueRoute:
+		return l.validateRoute(action, queue)
+	case actionTopicRoute:
+		return l.validateRoute(action, topic)
+	}
+	return fmt.Errorf("%w presign unsupported action: %s", ErrInvalidParameter, action)
+}
+
+// indexed params with numbered keys, ex: msgBody.0, msgBody.1
+//  input: params url.Values
+//  input: prefix string
+//  return: []string
+func indexed(params url.Values, prefix string) (list []string) {
+	for i := 0; ; i++ {
+		v, ok := params[prefix+`.`+strconv.Itoa(i)]
+		if !ok || len(v) == 0 {
+			return
+		}
+		list = append(list, v[0])
+	}
+}
@@ -0,0 +1,43 @@
+package tdmq
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestPresign presigned url is verified until expires, invalid params and unsigned modes are rejected
+func TestPresign(t *testing.T) {
+	c, err := NewClient(`http://gateway.example.com/`, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := url.Values{`queueName`: {`queue`}, `msgBody`: {`hello`}}
+	u, err := c.Presign(actionSendMsg, params, 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &Verifier{}
+	for i := 0; i < 2; i++ {
+		if err = v.Verify(`GET`, u.Host, u.Path, u.Query(), `secret`); err != nil {
+			t.Fatalf("verify presigned url %d: %v", i, err)
+		}
+	}
+	if err = (&Verifier{MaxTTL: time.Minute}).Verify(`GET`, u.Host, u.Path, u.Query(), `secret`); !errors.Is(err, ErrSignatureExpired) {
+		t.Fatalf("expect ttl longer than MaxTTL rejected: %v", err)
+	}
+
+	params.Set(`queueName`, `1queue`)
+	if _, err = c.Presign(actionSendMsg, params, time.Minute); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("expect invalid queue name: %v", err)
+	}
+	if _, err = c.Presign(`Unknown`, nil, time.Minute); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("expect unsupported action: %v", err)
+	}
+
+	c.SecretId, c.SecretKey, c.AppId = ``, ``, 1
+	if _, err = c.Presign(actionQueueRoute, url.Values{`queueName`: {`queue`}}, time.Minute); err == nil {
+		t.Fatal("expect presign rejected in appId mode")
+	}
+}
@@ -1,10 +1,10 @@
 package tdmq
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
-	"time"
 )
 
 type Queue struct {
@@ -22,6 +22,15 @@ func (q *Queue) Send(message string) (ResponseSM, error) {
 	return q.Client.SendMessage(q.Name, message, q.DelaySeconds)
 }
 
+// SendContext message
+//  input: ctx context.Context
+//  input: message string
+//  return: ResponseSM
+//  return: error
+func (q *Queue) SendContext(ctx context.Context, message string) (ResponseSM, error) {
+	return q.Client.SendMessageContext(ctx, q.Name, message, q.DelaySeconds)
+}
+
 // BatchSend message(s)
 //  input: messages ...string
 //  return: ResponseSMs
@@ -30,6 +39,15 @@ func (q *Queue) BatchSend(messages ...string) (ResponseSMs, error) {
 	return q.Client.BatchSendMessage(q.Name, messages, q.DelaySeconds)
 }
 
+// BatchSendContext message(s)
+//  input: ctx context.Context
+//  input: messages ...string
+//  return: ResponseSMs
+//  return: error
+func (q *Queue) BatchSendContext(ctx context.Context, messages ...string) (ResponseSMs, error) {
+	return q.Client.BatchSendMessageContext(ctx, q.Name, messages, q.DelaySeconds)
+}
+
 // Receive message
 //  return: ResponseRM
 //  return: error
@@ -37,6 +55,14 @@ func (q *Queue) Receive() (ResponseRM, error) {
 	return q.Client.ReceiveMessage(q.Name, q.PollingWaitSeconds)
 }
 
+// ReceiveContext message
+//  input: ctx context.Context
+//  return: ResponseRM
+//  return: error
+func (q *Queue) ReceiveContext(ctx context.Context) (ResponseRM, error) {
+	return q.Client.ReceiveMessageContext(ctx, q.Name, q.PollingWaitSeconds)
+}
+
 // BatchReceive message(s)
 //  input: numOfMsg int
 //  return: *ResponseRMs
@@ -45,6 +71,15 @@ func (q *Queue) BatchReceive(numOfMsg int) (ResponseRMs, error) {
 	return q.Client.BatchReceiveMessage(q.Name, q.PollingWaitSeconds, numOfMsg)
 }
 
+// BatchReceiveContext message(s)
+//  input: ctx context.Context
+//  input: numOfMsg int
+//  return: *ResponseRMs
+//  return: error
+func (q *Queue) BatchReceiveContext(ctx context.Context, numOfMsg int) (ResponseRMs, error) {
+	return q.Client.BatchReceiveMessageContext(ctx, q.Name, q.PollingWaitSeconds, numOfMsg)
+}
+
 // Delete message handle
 //  input: handle string
 //  return: ResponseDM
@@ -53,6 +88,15 @@ func (q *Queue) Delete(handle string) (ResponseDM, error) {
 	return q.Client.DeleteMessage(q.Name, handle)
 }
 
+// DeleteContext message handle
+//  input: ctx context.Context
+//  input: handle string
+//  return: ResponseDM
+//  return: error
+func (q *Queue) DeleteContext(ctx context.Context, handle string) (ResponseDM, error) {
+	return q.Client.DeleteMessageContext(ctx, q.Name, handle)
+}
+
 // BatchDelete message handle(s)
 //  input: handles ...string
 //  return: ResponseDMs
@@ -61,6 +105,15 @@ func (q *Queue) BatchDelete(handles ...string) (ResponseDMs, error) {
 	return q.Client.BatchDeleteMessage(q.Name, handles)
 }
 
+// BatchDeleteContext message handle(s)
+//  input: ctx context.Context
+//  input: handles ...string
+//  return: ResponseDMs
+//  return: error
+func (q *Queue) BatchDeleteContext(ctx context.Context, handles ...string) (ResponseDMs, error) {
+	return q.Client.BatchDeleteMessageContext(ctx, q.Name, handles)
+}
+
 // SendMessage
 //  API: https://cloud.tencent.com/document/product/406/5837
 //  input: queue string
@@ -69,13 +122,20 @@ func (q *Queue) BatchDelete(handles ...string) (ResponseDMs, error) {
 //  return: ResponseSM
 //  return: error
 func (c *Client) SendMessage(queue, message string, delaySeconds int) (ResponseSM, error) {
-	switch {
-	case !nameReg.MatchString(queue):
-		return nil, fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, MaxQueueNameSize+1, queue)
-	case message == `` || len(message) > MaxMessageSize:
-		return nil, fmt.Errorf("%w message length(0<len<%d): %d", ErrInvalidParameter, MaxMessageSize+1, len(message))
-	case delaySeconds < 0 || delaySeconds > MaxDelaySeconds:
-		return nil, fmt.Errorf("%w delay seconds[0~%d]: %d", ErrInvalidParameter, MaxDelaySeconds, delaySeconds)
+	return c.SendMessageContext(context.Background(), queue, message, delaySeconds)
+}
+
+// SendMessageContext
+//  API: https://cloud.tencent.com/document/product/406/5837
+//  input: ctx context.Context
+//  input: queue string
+//  input: message string
+//  input: delaySeconds int
+//  return: ResponseSM
+//  return: error
+func (c *Client) SendMessageContext(ctx context.Context, queue, message string, delaySeconds int) (ResponseSM, error) {
+	if err := c.limits().validateSend(queue, message, delaySeconds); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
@@ -83,7 +143,24 @@ func (c *Client) SendMessage(queue, message string, delaySeconds int) (ResponseS
 	values.Set(`queueName`, queue)
 	values.Set(`msgBody`, message)
 	values.Set(`delaySeconds`, strconv.Itoa(delaySeconds))
-	return c.call(values)
+	return result[ResponseSM](c.call(ctx, values))
+}
+
+// validateSend validate params of SendMessage
+//  input: queue string
+//  input: message string
+//  input: delaySeconds int
+//  return: error
+func (l Limits) validateSend(queue, message string, delaySeconds int) error {
+	switch {
+	case !validName(queue, l.MaxQueueNameSize):
+		return fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, l.MaxQueueNameSize+1, queue)
+	case message == `` || len(message) > l.MaxMessageSize:
+		return fmt.Errorf("%w message length(0<len<%d): %d", ErrInvalidParameter, l.MaxMessageSize+1, len(message))
+	case delaySeconds < 0 || delaySeconds > l.MaxDelaySeconds:
+		return fmt.Errorf("%w delay seconds[0~%d]: %d", ErrInvalidParameter, l.MaxDelaySeconds, delaySeconds)
+	}
+	return nil
 }
 
 // BatchSendMessage
@@ -94,19 +171,20 @@ func (c *Client) SendMessage(queue, message string, delaySeconds int) (ResponseS
 //  return: ResponseSMs
 //  return: error
 func (c *Client) BatchSendMessage(queue string, messages []string, delaySeconds int) (ResponseSMs, error) {
-	switch {
-	case !nameReg.MatchString(queue):
-		return nil, fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, MaxQueueNameSize+1, queue)
-	case len(messages) == 0 || len(messages) > MaxMessageCount:
-		return nil, fmt.Errorf("%w message count(0<len<%d): %d", ErrInvalidParameter, MaxMessageCount+1, len(messages))
-	case delaySeconds < 0 || delaySeconds > MaxDelaySeconds:
-		return nil, fmt.Errorf("%w delay seconds[0~%d]: %d", ErrInvalidParameter, MaxDelaySeconds, delaySeconds)
-	default:
-		for _, v := range messages {
-			if v == `` || len(v) > MaxMessageSize {
-				return nil, fmt.Errorf("%w message length(0<len<%d): %s", ErrInvalidParameter, MaxMessageSize+1, v)
-			}
-		}
+	return c.BatchSendMessageContext(context.Background(), queue, messages, delaySeconds)
+}
+
+// BatchSendMessageContext
+//  API: https://cloud.tencent.com/document/product/406/5838
+//  input: ctx context.Context
+//  input: queue string
+//  input: messages []string
+//  input: delaySeconds int
+//  return: ResponseSMs
+//  return: error
+func (c *Client) BatchSendMessageContext(ctx context.Context, queue string, messages []string, delaySeconds int) (ResponseSMs, error) {
+	if err := c.limits().validateBatchSend(queue, messages, delaySeconds); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
@@ -116,7 +194,30 @@ func (c *Client) BatchSendMessage(queue string, messages []string, delaySeconds
 	for i, m := range messages {
 		values.Set(`msgBody.`+strconv.Itoa(i), m)
 	}
-	return c.call(values)
+	return result[ResponseSMs](c.call(ctx, values))
+}
+
+// validateBatchSend validate params of BatchSendMessage
+//  input: queue string
+//  input: messages []string
+//  input: delaySeconds int
+//  return: error
+func (l Limits) validateBatchSend(queue string, messages []string, delaySeconds int) error {
+	switch {
+	case !validName(queue, l.MaxQueueNameSize):
+		return fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, l.MaxQueueNameSize+1, queue)
+	case len(messages) == 0 || len(messages) > l.MaxMessageCount:
+		return fmt.Errorf("%w message count(0<len<%d): %d", ErrInvalidParameter, l.MaxMessageCount+1, len(messages))
+	case delaySeconds < 0 || delaySeconds > l.MaxDelaySeconds:
+		return fmt.Errorf("%w delay seconds[0~%d]: %d", ErrInvalidParameter, l.MaxDelaySeconds, delaySeconds)
+	default:
+		for _, v := range messages {
+			if v == `` || len(v) > l.MaxMessageSize {
+				return fmt.Errorf("%w message length(0<len<%d): %s", ErrInvalidParameter, l.MaxMessageSize+1, v)
+			}
+		}
+	}
+	return nil
 }
 
 // ReceiveMessage
@@ -126,23 +227,40 @@ func (c *Client) BatchSendMessage(queue string, messages []string, delaySeconds
 //  return: ResponseRM
 //  return: error
 func (c *Client) ReceiveMessage(queue string, pollingWaitSeconds int) (ResponseRM, error) {
-	switch {
-	case !nameReg.MatchString(queue):
-		return nil, fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, MaxQueueNameSize+1, queue)
-	case pollingWaitSeconds < 0 || pollingWaitSeconds > MaxWaitSeconds:
-		return nil, fmt.Errorf("%w polling wait seconds[0~%d]: %d", ErrInvalidParameter, MaxWaitSeconds, pollingWaitSeconds)
+	return c.ReceiveMessageContext(context.Background(), queue, pollingWaitSeconds)
+}
+
+// ReceiveMessageContext
+//  API: https://cloud.tencent.com/document/product/406/5839
+//  input: ctx context.Context
+//  input: queue string
+//  input: pollingWaitSeconds int
+//  return: ResponseRM
+//  return: error
+func (c *Client) ReceiveMessageContext(ctx context.Context, queue string, pollingWaitSeconds int) (ResponseRM, error) {
+	if err := c.limits().validateReceive(queue, pollingWaitSeconds); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
 	values.Set(`Action`, actionRecvMsg)
 	values.Set(`queueName`, queue)
 	values.Set(`pollingWaitSeconds`, strconv.Itoa(pollingWaitSeconds))
+	return result[ResponseRM](c.call(ctx, values))
+}
 
-	t := time.Duration(pollingWaitSeconds) * time.Second
-	if t > c.HttpClient.Timeout {
-		c.HttpClient.Timeout = t + time.Second
+// validateReceive validate params of ReceiveMessage
+//  input: queue string
+//  input: pollingWaitSeconds int
+//  return: error
+func (l Limits) validateReceive(queue string, pollingWaitSeconds int) error {
+	switch {
+	case !validName(queue, l.MaxQueueNameSize):
+		return fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, l.MaxQueueNameSize+1, queue)
+	case pollingWaitSeconds < 0 || pollingWaitSeconds > l.MaxWaitSeconds:
+		return fmt.Errorf("%w polling wait seconds[0~%d]: %d", ErrInvalidParameter, l.MaxWaitSeconds, pollingWaitSeconds)
 	}
-	return c.call(values)
+	return nil
 }
 
 // BatchReceiveMessage
@@ -153,13 +271,20 @@ func (c *Client) ReceiveMessage(queue string, pollingWaitSeconds int) (ResponseR
 //  return: *ResponseRMs
 //  return: error
 func (c *Client) BatchReceiveMessage(queue string, pollingWaitSeconds, numOfMsg int) (ResponseRMs, error) {
-	switch {
-	case !nameReg.MatchString(queue):
-		return nil, fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, MaxQueueNameSize+1, queue)
-	case pollingWaitSeconds < 0 || pollingWaitSeconds > MaxWaitSeconds:
-		return nil, fmt.Errorf("%w polling wait seconds[0~%d]: %d", ErrInvalidParameter, MaxWaitSeconds, pollingWaitSeconds)
-	case numOfMsg < 1 || numOfMsg > 16:
-		return nil, fmt.Errorf("%w number of message[1~%d]: %d", ErrInvalidParameter, MaxMessageCount, pollingWaitSeconds)
+	return c.BatchReceiveMessageContext(context.Background(), queue, pollingWaitSeconds, numOfMsg)
+}
+
+// BatchReceiveMessageContext
+//  API: https://cloud.tencent.com/document/product/406/5924
+//  input: ctx context.Context
+//  input: queue string
+//  input: pollingWaitSeconds int
+//  input: numOfMsg int
+//  return: *ResponseRMs
+//  return: error
+func (c *Client) BatchReceiveMessageContext(ctx context.Context, queue string, pollingWaitSeconds, numOfMsg int) (ResponseRMs, error) {
+	if err := c.limits().validateBatchReceive(queue, pollingWaitSeconds, numOfMsg); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
@@ -167,12 +292,24 @@ func (c *Client) BatchReceiveMessage(queue string, pollingWaitSeconds, numOfMsg
 	values.Set(`queueName`, queue)
 	values.Set(`pollingWaitSeconds`, strconv.Itoa(pollingWaitSeconds))
 	values.Set(`numOfMsg`, strconv.Itoa(numOfMsg))
+	return result[ResponseRMs](c.call(ctx, values))
+}
 
-	t := time.Duration(pollingWaitSeconds) * time.Second
-	if t > c.HttpClient.Timeout {
-		c.HttpClient.Timeout = t + time.Second
+// validateBatchReceive validate params of BatchReceiveMessage
+//  input: queue string
+//  input: pollingWaitSeconds int
+//  input: numOfMsg int
+//  return: error
+func (l Limits) validateBatchReceive(queue string, pollingWaitSeconds, numOfMsg int) error {
+	switch {
+	case !validName(queue, l.MaxQueueNameSize):
+		return fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, l.MaxQueueNameSize+1, queue)
+	case pollingWaitSeconds < 0 || pollingWaitSeconds > l.MaxWaitSeconds:
+		return fmt.Errorf("%w polling wait seconds[0~%d]: %d", ErrInvalidParameter, l.MaxWaitSeconds, pollingWaitSeconds)
+	case numOfMsg < 1 || numOfMsg > l.MaxMessageCount:
+		return fmt.Errorf("%w number of message[1~%d]: %d", ErrInvalidParameter, l.MaxMessageCount, numOfMsg)
 	}
-	return c.call(values)
+	return nil
 }
 
 // DeleteMessage
@@ -182,18 +319,40 @@ func (c *Client) BatchReceiveMessage(queue string, pollingWaitSeconds, numOfMsg
 //  return: ResponseDM
 //  return: error
 func (c *Client) DeleteMessage(queue, receiptHandle string) (ResponseDM, error) {
-	switch {
-	case !nameReg.MatchString(queue):
-		return nil, fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, MaxQueueNameSize+1, queue)
-	case !handleReg.MatchString(receiptHandle):
-		return nil, fmt.Errorf("%w receipt handle(0<len<%d): %s", ErrInvalidParameter, MaxHandleLength+1, receiptHandle)
+	return c.DeleteMessageContext(context.Background(), queue, receiptHandle)
+}
+
+// DeleteMessageContext
+//  API: https://cloud.tencent.com/document/product/406/5840
+//  input: ctx context.Context
+//  input: queue string
+//  input: receiptHandle string
+//  return: ResponseDM
+//  return: error
+func (c *Client) DeleteMessageContext(ctx context.Context, queue, receiptHandle string) (ResponseDM, error) {
+	if err := c.limits().validateDelete(queue, receiptHandle); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
 	values.Set(`Action`, actionDelMsg)
 	values.Set(`queueName`, queue)
 	values.Set(`receiptHandle`, receiptHandle)
-	return c.call(values)
+	return result[ResponseDM](c.call(ctx, values))
+}
+
+// validateDelete validate params of DeleteMessage
+//  input: queue string
+//  input: receiptHandle string
+//  return: error
+func (l Limits) validateDelete(queue, receiptHandle string) error {
+	switch {
+	case !validName(queue, l.MaxQueueNameSize):
+		return fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, l.MaxQueueNameSize+1, queue)
+	case !validHandle(receiptHandle, l.MaxHandleLength):
+		return fmt.Errorf("%w receipt handle(0<len<%d): %s", ErrInvalidParameter, l.MaxHandleLength+1, receiptHandle)
+	}
+	return nil
 }
 
 // BatchDeleteMessage
@@ -203,17 +362,19 @@ func (c *Client) DeleteMessage(queue, receiptHandle string) (ResponseDM, error)
 //  return: ResponseDMs
 //  return: error
 func (c *Client) BatchDeleteMessage(queue string, receiptHandles []string) (ResponseDMs, error) {
-	switch {
-	case !nameReg.MatchString(queue):
-		return nil, fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, MaxQueueNameSize+1, queue)
-	case len(receiptHandles) == 0 || len(receiptHandles) > MaxHandleCount:
-		return nil, fmt.Errorf("%w receipt handle count[0~%d]: %v", ErrInvalidParameter, MaxHandleCount, receiptHandles)
-	default:
-		for _, h := range receiptHandles {
-			if !handleReg.MatchString(h) {
-				return nil, fmt.Errorf("%w receipt handle(0<len<%d): %s", ErrInvalidParameter, MaxHandleLength+1, h)
-			}
-		}
+	return c.BatchDeleteMessageContext(context.Background(), queue, receiptHandles)
+}
+
+// BatchDeleteMessageContext
+//  API: https://cloud.tencent.com/document/product/406/5841
+//  input: ctx context.Context
+//  input: queue string
+//  input: receiptHandles []string
+//  return: ResponseDMs
+//  return: error
+func (c *Client) BatchDeleteMessageContext(ctx context.Context, queue string, receiptHandles []string) (ResponseDMs, error) {
+	if err := c.limits().validateBatchDelete(queue, receiptHandles); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
@@ -222,5 +383,25 @@ func (c *Client) BatchDeleteMessage(queue string, receiptHandles []string) (Resp
 	for i, h := range receiptHandles {
 		values.Set(`receiptHandle.`+strconv.Itoa(i), h)
 	}
-	return c.call(values)
+	return result[ResponseDMs](c.call(ctx, values))
+}
+
+// validateBatchDelete validate params of BatchDeleteMessage
+//  input: queue string
+//  input: receiptHandles []string
+//  return: error
+func (l Limits) validateBatchDelete(queue string, receiptHandles []string) error {
+	switch {
+	case !validName(queue, l.MaxQueueNameSize):
+		return fmt.Errorf("%w queue name(0<len<%d): %s", ErrInvalidParameter, l.MaxQueueNameSize+1, queue)
+	case len(receiptHandles) == 0 || len(receiptHandles) > l.MaxHandleCount:
+		return fmt.Errorf("%w receipt handle count[0~%d]: %v", ErrInvalidParameter, l.MaxHandleCount, receiptHandles)
+	default:
+		for _, h := range receiptHandles {
+			if !validHandle(h, l.MaxHandleLength) {
+				return fmt.Errorf("%w receipt handle(0<len<%d): %s", ErrInvalidParameter, l.MaxHandleLength+1, h)
+			}
+		}
+	}
+	return nil
 }
@@ -0,0 +1,121 @@
+package tdmq
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+// RetryCodes CMQ response codes which are worth to retry
+var RetryCodes = []int{
+	CodeThrottled,
+	CodeInternalError,
+}
+
+// RetryPolicy retry failed request with exponential backoff and jitter
+//  transport errors, 429/5xx response and RetryCodes are retried, Retry-After header is honored
+//  unless it's longer than MaxBackoff, then the request is not retried,
+//  SendMessage/PublishMessage (and batch) may cause duplicate messages, they are only retried when Unsafe is set.
+type RetryPolicy struct {
+	MaxAttempts int           // max attempts include the first request, <=1 means no retry
+	MinBackoff  time.Duration // backoff before the first retry, default: 100ms
+	MaxBackoff  time.Duration // upper bound of backoff, default: 5s
+	Codes       []int         // retryable CMQ response codes, default: RetryCodes
+	Unsafe      bool          // also retry actions which risk duplicate messages
+}
+
+// retryable check whether the request should be retried
+//  input: action string
+//  input: attempt int attempts already done
+//  input: msg *msgResponse
+//  input: err error
+//  return: bool
+func (p *RetryPolicy) retryable(action string, attempt int, msg *msgResponse, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	if !p.Unsafe && !idempotent(action) {
+		return false
+	}
+	switch {
+	case msg != nil && msg.Status >= 500:
+		return true
+	case errors.Is(err, ErrClockSkew):
+		return true // timestamp is compensated by tracked clock skew
+	case err != nil:
+		var he *HTTPError
+		if !errors.As(err, &he) {
+			var e *url.Error
+			return errors.As(err, &e) // transport error from http client
+		}
+		if he.RetryAfter > p.maxBackoff() {
+			return false // server asks to wait longer than the caller accepts
+		}
+		if he.retryable() || he.Err != nil || msg == nil {
+			return he.retryable()
+		}
+	case msg == nil:
+		return false
+	}
+	codes := p.Codes
+	if codes == nil {
+		codes = RetryCodes
+	}
+	for _, code := range codes {
+		if msg.Code_ == code {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBackoff upper bound of backoff
+//  return: time.Duration
+func (p *RetryPolicy) maxBackoff() time.Duration {
+	if p.MaxBackoff <= 0 {
+		return 5 * time.Second
+	}
+	return p.MaxBackoff
+}
+
+// backoff exponential backoff with jitter in [d/2, d), or Retry-After of response if it's longer, at most MaxBackoff
+//  input: attempt int attempts already done
+//  input: err error of the last attempt
+//  input: r Rand for jitter
+//  return: time.Duration
+func (p *RetryPolicy) backoff(attempt int, err error, r Rand) time.Duration {
+	var he *HTTPError
+	if errors.As(err, &he) && he.RetryAfter > 0 {
+		if d := p.backoff(attempt, nil, r); d > he.RetryAfter {
+			return d
+		}
+		if max := p.maxBackoff(); he.RetryAfter > max {
+			return max
+		}
+		return he.RetryAfter
+	}
+	lower, upper := p.MinBackoff, p.maxBackoff()
+	if lower <= 0 {
+		lower = 100 * time.Millisecond
+	}
+	d := lower
+	for i := 1; i < attempt && d < upper; i++ {
+		d *= 2
+	}
+	if d > upper {
+		d = upper
+	}
+	half := int64(d / 2)
+	return time.Duration(half + r.Int63n(half+1))
+}
+
+// idempotent actions are safe to retry, the others may cause duplicate messages
+//  input: action string
+//  return: bool
+func idempotent(action string) bool {
+	switch action {
+	case actionQueueRoute, actionTopicRoute, actionRecvMsg, actionBatchRecv, actionDelMsg, actionBatchDel:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package tdmq
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// TestRetryAfterCap Retry-After longer than MaxBackoff is not waited for
+func TestRetryAfterCap(t *testing.T) {
+	p := &RetryPolicy{MaxAttempts: 3, MaxBackoff: time.Second}
+	long := &HTTPError{Status: http.StatusTooManyRequests, RetryAfter: time.Hour}
+	if p.retryable(actionRecvMsg, 1, nil, long) {
+		t.Fatal("retry with Retry-After longer than MaxBackoff")
+	}
+	if d := p.backoff(1, long, NewRand(1)); d > time.Second {
+		t.Fatalf("backoff %s longer than MaxBackoff", d)
+	}
+	short := &HTTPError{Status: http.StatusTooManyRequests, RetryAfter: 500 * time.Millisecond}
+	if !p.retryable(actionRecvMsg, 1, nil, short) {
+		t.Fatal("no retry with short Retry-After")
+	}
+	if d := p.backoff(1, short, NewRand(1)); d < 500*time.Millisecond {
+		t.Fatalf("backoff %s shorter than Retry-After", d)
+	}
+}
+
+// TestRetryable classification of results and actions
+func TestRetryable(t *testing.T) {
+	transport := &url.Error{Op: `Post`, URL: `http://gateway`, Err: errors.New(`connection refused`)}
+	cases := []struct {
+		name   string
+		policy *RetryPolicy
+		action string
+		msg    *msgResponse
+		err    error
+		expect bool
+	}{
+		{`nil policy`, nil, actionRecvMsg, nil, transport, false},
+		{`transport error`, &RetryPolicy{MaxAttempts: 3}, actionRecvMsg, nil, transport, true},
+		{`send not idempotent`, &RetryPolicy{MaxAttempts: 3}, actionSendMsg, nil, transport, false},
+		{`publish not idempotent`, &RetryPolicy{MaxAttempts: 3}, actionBatchPub, nil, transport, false},
+		{`send unsafe`, &RetryPolicy{MaxAttempts: 3, Unsafe: true}, actionSendMsg, nil, transport, true},
+		{`5xx`, &RetryPolicy{MaxAttempts: 3}, actionDelMsg, &msgResponse{Status: 502}, &HTTPError{Status: 502}, true},
+		{`429`, &RetryPolicy{MaxAttempts: 3}, actionDelMsg, &msgResponse{Status: 429}, &HTTPError{Status: 429}, true},
+		{`4xx`, &RetryPolicy{MaxAttempts: 3}, actionDelMsg, &msgResponse{Status: 400}, &HTTPError{Status: 400}, false},
+		{`RetryCodes`, &RetryPolicy{MaxAttempts: 3}, actionQueueRoute, &msgResponse{Status: 200, Code_: CodeThrottled}, nil, true},
+		{`not in RetryCodes`, &RetryPolicy{MaxAttempts: 3}, actionQueueRoute, &msgResponse{Status: 200, Code_: CodeAuthFailed}, nil, false},
+		{`Codes override`, &RetryPolicy{MaxAttempts: 3, Codes: []int{CodeResourceNotExist}}, actionQueueRoute, &msgResponse{Status: 200, Code_: CodeResourceNotExist}, nil, true},
+		{`Codes exclude default`, &RetryPolicy{MaxAttempts: 3, Codes: []int{CodeResourceNotExist}}, actionQueueRoute, &msgResponse{Status: 200, Code_: CodeThrottled}, nil, false},
+		{`success`, &RetryPolicy{MaxAttempts: 3}, actionRecvMsg, &msgResponse{Status: 200}, nil, false},
+	}
+	for _, c := range cases {
+		if got := c.policy.retryable(c.action, 1, c.msg, c.err); got != c.expect {
+			t.Errorf("%s: retryable %v, expect %v", c.name, got, c.expect)
+		}
+	}
+	p := &RetryPolicy{MaxAttempts: 3}
+	if !p.retryable(actionRecvMsg, 2, nil, transport) || p.retryable(actionRecvMsg, 3, nil, transport) {
+		t.Error("MaxAttempts includes the first request")
+	}
+}
+
+// TestBackoff exponential backoff with jitter in [d/2, d), capped by MaxBackoff
+func TestBackoff(t *testing.T) {
+	p := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	r := NewRand(1)
+	for attempt, d := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 3: 400 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 10: time.Second} {
+		for i := 0; i < 100; i++ {
+			if b := p.backoff(attempt, nil, r); b < d/2 || b > d {
+				t.Fatalf("attempt %d: backoff %s out of [%s, %s]", attempt, b, d/2, d)
+			}
+		}
+	}
+}
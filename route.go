@@ -1,6 +1,7 @@
 package tdmq
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 )
@@ -10,7 +11,16 @@ import (
 //  return: *ResponseRoute
 //  return: error
 func (c *Client) QueryQueueRoute(queue string) (Route, error) {
-	return c.query(actionQueueRoute, queue)
+	return c.QueryQueueRouteContext(context.Background(), queue)
+}
+
+// QueryQueueRouteContext
+//  input: ctx context.Context
+//  input: queue string
+//  return: *ResponseRoute
+//  return: error
+func (c *Client) QueryQueueRouteContext(ctx context.Context, queue string) (Route, error) {
+	return c.query(ctx, actionQueueRoute, queue)
 }
 
 // QueryTopicRoute
@@ -18,17 +28,27 @@ func (c *Client) QueryQueueRoute(queue string) (Route, error) {
 //  return: *ResponseRoute
 //  return: error
 func (c *Client) QueryTopicRoute(topic string) (Route, error) {
-	return c.query(actionTopicRoute, topic)
+	return c.QueryTopicRouteContext(context.Background(), topic)
+}
+
+// QueryTopicRouteContext
+//  input: ctx context.Context
+//  input: topic string
+//  return: *ResponseRoute
+//  return: error
+func (c *Client) QueryTopicRouteContext(ctx context.Context, topic string) (Route, error) {
+	return c.query(ctx, actionTopicRoute, topic)
 }
 
 // query
+//  input: ctx context.Context
 //  input: action string
 //  input: name string
 //  return: *ResponseRoute
 //  return: error
-func (c *Client) query(action, name string) (Route, error) {
-	if name == `` || len(name) > 64 {
-		return nil, fmt.Errorf("%w %s name(0<len<65): %s", ErrInvalidParameter, action, name)
+func (c *Client) query(ctx context.Context, action, name string) (Route, error) {
+	if err := c.limits().validateRoute(action, name); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
@@ -38,5 +58,20 @@ func (c *Client) query(action, name string) (Route, error) {
 	} else {
 		values.Set(`topicName`, name)
 	}
-	return c.call(values)
+	return result[Route](c.call(ctx, values))
+}
+
+// validateRoute validate params of QueryQueueRoute and QueryTopicRoute
+//  input: action string
+//  input: name string queue or topic name
+//  return: error
+func (l Limits) validateRoute(action, name string) error {
+	max := l.MaxQueueNameSize
+	if action == actionTopicRoute {
+		max = l.MaxTopicNameSize
+	}
+	if name == `` || len(name) > max {
+		return fmt.Errorf("%w %s name(0<len<%d): %s", ErrInvalidParameter, action, max+1, name)
+	}
+	return nil
 }
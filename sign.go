@@ -1,61 +1,134 @@
 package tdmq
 
 import (
+	"context"
 	"crypto/hmac"
-	"crypto/sha1"
 	"crypto/sha256"
-	"encoding/base64"
-	"fmt"
-	"hash"
-	"net/url"
-	"sort"
+	"encoding/hex"
+	"net/http"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
-	HmacSHA1   = `HmacSHA1`
-	HmacSHA256 = `HmacSHA256`
+	HmacSHA1      = `HmacSHA1`
+	HmacSHA256    = `HmacSHA256`
+	TC3HmacSHA256 = `TC3-HMAC-SHA256` // signature v3 in Authorization header
+
+	tc3Service = `tdmq`
 )
 
-func (c *Client) sign(host string, values url.Values) string {
-	plain := []string{c.Method}
-	if len(host) > 0 {
-		plain = append(plain, host)
-	}
-	if path := c.Url.Path; len(path) > 0 {
-		idx := strings.Index(path, `?`)
-		if idx >= 0 {
-			path = path[:idx]
-		}
-		plain = append(plain, path)
+// signHMAC sign request with credential, HmacSHA1/HmacSHA256 in params or TC3-HMAC-SHA256 in Authorization header
+//  input: ctx context.Context
+//  input: r *AuthRequest
+//  input: cred *Credential
+//  input: method string sign method
+//  input: service string service of TC3-HMAC-SHA256
+func signHMAC(ctx context.Context, r *AuthRequest, cred *Credential, method, service string) {
+	if method == TC3HmacSHA256 {
+		plain := signTC3(r, cred, service)
+		r.rl.log(ctx, LevelDebug, `string to sign`, `plain`, plain)
+		return
 	}
-	plain = append(plain, `?`)
-	var keys []string
-	for k := range values {
-		keys = append(keys, k)
+	if cred.Token != `` {
+		r.Set(`Token`, cred.Token)
 	}
-	sort.Strings(keys)
-	for _, k := range keys {
-		k = strings.Replace(k, `_`, `.`, -1)
-		plain = append(plain, k, `=`, strings.Join(values[k], ``), `&`)
+	r.Set(`SecretId`, cred.SecretId)
+	r.Set(`SignatureMethod`, method)
+	r.Set(`Nonce`, strconv.FormatUint(uint64(r.Nonce()), 10))
+	r.Set(`Timestamp`, strconv.FormatInt(r.Time.Unix(), 10))
+	if r.rl.enabled(LevelDebug) {
+		redacted := newEncoder(r.rl.values(r.e.values()))
+		redacted.sort()
+		r.rl.log(ctx, LevelDebug, `string to sign`, `plain`, string(redacted.stringToSign(r.Method, r.URL)))
+		redacted.free()
 	}
+	r.Set(`Signature`, signature(method, cred.SecretKey, r.StringToSign()))
+}
 
-	if plain[len(plain)-1] == `&` {
-		plain = plain[:len(plain)-1]
+// signTC3 sign request with TC3-HMAC-SHA256 in Authorization header
+//  https://cloud.tencent.com/document/api/1179/46132
+//  input: r *AuthRequest
+//  input: cred *Credential
+//  input: service string
+//  return: string string to sign
+func signTC3(r *AuthRequest, cred *Credential, service string) string {
+	if service == `` {
+		service = tc3Service
 	}
-	if c.Debug {
-		fmt.Println("String to sign:", strings.Join(plain, ``))
+	host := r.URL.Host
+	contentType := r.Header.Get(`Content-Type`)
+	path := r.URL.EscapedPath()
+	if path == `` {
+		path = `/`
 	}
-
-	var h hash.Hash
-	if c.SignMethod == HmacSHA256 {
-		h = hmac.New(sha256.New, []byte(c.SecretKey))
+	query, payload := r.URL.RawQuery, []byte(nil)
+	if r.Method == http.MethodGet {
+		query = string(r.Payload())
 	} else {
-		h = hmac.New(sha1.New, []byte(c.SecretKey))
+		payload = r.Payload()
 	}
-	_, err := h.Write([]byte(strings.Join(plain, ``)))
-	if err != nil {
-		return ``
+	timestamp := r.Time.Unix()
+	_, plain, authorization := tc3Sign(r.Method, host, path, query, contentType, payload, timestamp, service, cred)
+
+	r.Header.Set(`Authorization`, authorization)
+	r.Header.Set(`X-TC-Action`, r.Action)
+	r.Header.Set(`X-TC-Timestamp`, strconv.FormatInt(timestamp, 10))
+	if cred.Token != `` {
+		r.Header.Set(`X-TC-Token`, cred.Token)
 	}
-	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return plain
+}
+
+// tc3Sign build canonical request, string to sign and Authorization header of TC3-HMAC-SHA256
+//  input: method string GET, POST
+//  input: host string
+//  input: path string escaped path
+//  input: query string canonical query, empty for POST
+//  input: contentType string
+//  input: payload []byte body of request, nil for GET
+//  input: timestamp int64
+//  input: service string
+//  input: cred *Credential
+//  return: canonical string
+//  return: plain string string to sign
+//  return: authorization string
+func tc3Sign(method, host, path, query, contentType string, payload []byte, timestamp int64, service string, cred *Credential) (canonical, plain, authorization string) {
+	payloadHash := sha256.Sum256(payload)
+	canonical = strings.Join([]string{
+		method,
+		path,
+		query,
+		`content-type:` + contentType + "\n" + `host:` + host + "\n",
+		`content-type;host`,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+
+	date := time.Unix(timestamp, 0).UTC().Format(`2006-01-02`)
+	scope := date + `/` + service + `/tc3_request`
+	canonicalHash := sha256.Sum256([]byte(canonical))
+	plain = strings.Join([]string{
+		TC3HmacSHA256,
+		strconv.FormatInt(timestamp, 10),
+		scope,
+		hex.EncodeToString(canonicalHash[:]),
+	}, "\n")
+
+	key := hmacSHA256([]byte(`TC3`+cred.SecretKey), date)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, `tc3_request`)
+	signature := hex.EncodeToString(hmacSHA256(key, plain))
+	authorization = TC3HmacSHA256 + ` Credential=` + cred.SecretId + `/` + scope + `, SignedHeaders=content-type;host, Signature=` + signature
+	return
+}
+
+// hmacSHA256
+//  input: key []byte
+//  input: msg string
+//  return: []byte
+func hmacSHA256(key []byte, msg string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(msg))
+	return h.Sum(nil)
 }
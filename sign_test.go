@@ -0,0 +1,71 @@
+package tdmq
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tc3Credential and tc3Time of the example in TC3-HMAC-SHA256 document
+//  https://cloud.tencent.com/document/api/213/30654
+var (
+	tc3Credential = &Credential{SecretId: `AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******`, SecretKey: `Gu5t9xGARNpq86cd98joQYCN3*******`}
+	tc3Time       = time.Unix(1551113065, 0)
+)
+
+// TestTC3Sign known answer of the published example
+func TestTC3Sign(t *testing.T) {
+	payload := `{"Limit": 1, "Filters": [{"Values": ["\u672a\u547d\u540d"], "Name": "instance-name"}]}`
+	canonical, plain, authorization := tc3Sign(http.MethodPost, `cvm.tencentcloudapi.com`, `/`, ``,
+		`application/json; charset=utf-8`, []byte(payload), tc3Time.Unix(), `cvm`, tc3Credential)
+	expect := "POST\n/\n\ncontent-type:application/json; charset=utf-8\nhost:cvm.tencentcloudapi.com\n\ncontent-type;host\n" +
+		`35e9c5b0e3ae67532d3c9f17ead6c90222632e5b1ff7f6e89887f1398934f064`
+	if canonical != expect {
+		t.Errorf("canonical request:\n%s\nexpect:\n%s", canonical, expect)
+	}
+	expect = "TC3-HMAC-SHA256\n1551113065\n2019-02-25/cvm/tc3_request\n" +
+		`5ffe6a04c0664d6b969fab9a13bdab201d63ee709638e2749d62a09ca18d7031`
+	if plain != expect {
+		t.Errorf("string to sign:\n%s\nexpect:\n%s", plain, expect)
+	}
+	expect = `TC3-HMAC-SHA256 Credential=AKIDz8krbsJ5yKBZQpn74WFkmLPx3*******/2019-02-25/cvm/tc3_request, SignedHeaders=content-type;host, ` +
+		`Signature=2230eefd229f582d8b1b891af7107b91597240707d778ab3738f756258d7652c`
+	if authorization != expect {
+		t.Errorf("authorization:\n%s\nexpect:\n%s", authorization, expect)
+	}
+}
+
+// TestSignTC3 params in canonical query of GET, hash of form body in canonical request of POST
+func TestSignTC3(t *testing.T) {
+	cases := []struct {
+		method    string
+		hash      string // hash of canonical request
+		signature string
+	}{
+		{http.MethodGet, `91c9c192c14460df6c1ffc69e34e6c5e90708de2a6d282cccf957dbf1aa7f3a7`, `83ea459dcc7529689abdf0ac4d5bde3b9f5df95383b0ba9bcedbc1426c1ebc00`},
+		{http.MethodPost, `c74b4a956205cff07e6e1fde1ec319447771886fc2514ac89e2ef1db95e54646`, `a3d07b85e7946efc91e8eba5fb8bad5497a6159b2ad26a159a92159b5b155302`},
+	}
+	for _, c := range cases {
+		r := &AuthRequest{
+			Action: `DescribeInstances`,
+			Method: c.method,
+			URL:    &url.URL{Scheme: `https`, Host: `cvm.tencentcloudapi.com`},
+			Header: http.Header{`Content-Type`: {`application/x-www-form-urlencoded`}},
+			Time:   tc3Time,
+			e:      newEncoder(url.Values{`Offset`: {`0`}, `Limit`: {`10`}}),
+		}
+		plain := signTC3(r, &Credential{SecretId: tc3Credential.SecretId, SecretKey: tc3Credential.SecretKey, Token: `token`}, `cvm`)
+		if !strings.HasSuffix(plain, "\n"+c.hash) {
+			t.Errorf("%s string to sign:\n%s\nexpect hash: %s", c.method, plain, c.hash)
+		}
+		if auth := r.Header.Get(`Authorization`); !strings.HasSuffix(auth, `, Signature=`+c.signature) {
+			t.Errorf("%s authorization: %s\nexpect signature: %s", c.method, auth, c.signature)
+		}
+		if r.Header.Get(`X-TC-Action`) != `DescribeInstances` || r.Header.Get(`X-TC-Timestamp`) != `1551113065` || r.Header.Get(`X-TC-Token`) != `token` {
+			t.Errorf("%s headers: %v", c.method, r.Header)
+		}
+		r.e.free()
+	}
+}
@@ -0,0 +1,78 @@
+package tdmq
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+var ErrClockSkew = errors.New("clock skew")
+
+// ClockSkew offset of server clock to local clock, tracked by Date header of responses
+//  return: time.Duration
+func (c *Client) ClockSkew() time.Duration {
+	return time.Duration(atomic.LoadInt64(&c.skew))
+}
+
+// now time of client clock compensated by clock skew, for Timestamp of signed request
+//  return: time.Time
+func (c *Client) now() time.Time {
+	now := c.clock().Now()
+	if c.NoSkew {
+		return now
+	}
+	return now.Add(c.ClockSkew())
+}
+
+// trackSkew estimate clock skew by Date header of response
+//  the Date header has 1 second resolution, offset less than 1 second is ignored
+//  input: resp *http.Response
+//  input: start time.Time request sent
+//  input: end time.Time response received
+func (c *Client) trackSkew(resp *http.Response, start, end time.Time) {
+	date, err := http.ParseTime(resp.Header.Get(`Date`))
+	if err != nil {
+		return
+	}
+	local := start.Add(end.Sub(start) / 2)
+	skew := date.Add(500 * time.Millisecond).Sub(local) // server time is in [Date, Date+1s)
+	if skew > -time.Second && skew < time.Second {
+		skew = 0
+	}
+	atomic.StoreInt64(&c.skew, int64(skew.Round(time.Second)))
+}
+
+// ClockSkewError request rejected for Timestamp out of the window of server, it's ErrClockSkew and unwraps to *APIError
+type ClockSkewError struct {
+	Skew time.Duration // tracked server clock offset, see Client.ClockSkew
+	Err  *APIError
+}
+
+func (e *ClockSkewError) Error() string {
+	return fmt.Sprintf("%s: %s, server clock offset: %s", ErrClockSkew, e.Err, e.Skew)
+}
+
+func (e *ClockSkewError) Is(target error) bool {
+	return target == ErrClockSkew
+}
+
+func (e *ClockSkewError) Unwrap() error {
+	return e.Err
+}
+
+// skewError check whether request is rejected for timestamp, credential or token expiration is not clock skew
+//  input: action string
+//  input: msg *msgResponse
+//  return: error
+func (c *Client) skewError(action string, msg *msgResponse) error {
+	if msg.Code_ != CodeAuthFailed && msg.Code_ != CodeInvalidParameter {
+		return nil
+	}
+	if !strings.Contains(strings.ToLower(msg.Message_), `timestamp`) {
+		return nil
+	}
+	return &ClockSkewError{Skew: c.ClockSkew(), Err: apiError(action, msg).(*APIError)}
+}
@@ -0,0 +1,37 @@
+package tdmq
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestSkewError only timestamp rejection is clock skew, the result and *APIError are kept
+func TestSkewError(t *testing.T) {
+	var message string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"code":4100,"message":"` + message + `"}`))
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message = `token expired`
+	res, err := c.SendMessage(`queue`, `message`, 0)
+	if err != nil || res.Code() != CodeAuthFailed {
+		t.Fatalf("credential expiration is not clock skew: %v", err)
+	}
+
+	message = `timestamp expired`
+	res, err = c.SendMessage(`queue`, `message`, 0)
+	if !errors.Is(err, ErrClockSkew) || !errors.Is(err, ErrAuthFailed) {
+		t.Fatalf("expect clock skew and auth failed: %v", err)
+	}
+	if res == nil || res.Code() != CodeAuthFailed {
+		t.Fatalf("result lost: %v", res)
+	}
+}
@@ -1,6 +1,7 @@
 package tdmq
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"strconv"
@@ -18,10 +19,18 @@ func (t *Topic) Publish(message string) (ResponseSM, error) {
 	return t.Client.PublishMessage(t.Name, message, t.RoutingKey, t.Tags)
 }
 
+func (t *Topic) PublishContext(ctx context.Context, message string) (ResponseSM, error) {
+	return t.Client.PublishMessageContext(ctx, t.Name, message, t.RoutingKey, t.Tags)
+}
+
 func (t *Topic) BatchPublish(messages ...string) (ResponseSMs, error) {
 	return t.Client.BatchPublishMessage(t.Name, t.RoutingKey, messages, t.Tags)
 }
 
+func (t *Topic) BatchPublishContext(ctx context.Context, messages ...string) (ResponseSMs, error) {
+	return t.Client.BatchPublishMessageContext(ctx, t.Name, t.RoutingKey, messages, t.Tags)
+}
+
 // PublishMessage
 //  API: https://cloud.tencent.com/document/product/406/7411
 //  input: topic string
@@ -31,24 +40,21 @@ func (t *Topic) BatchPublish(messages ...string) (ResponseSMs, error) {
 //  return: ResponseSM
 //  return: error
 func (c *Client) PublishMessage(topic, message, routingKey string, tags []string) (ResponseSM, error) {
-	switch {
-	case !nameReg.MatchString(topic):
-		return nil, fmt.Errorf("%w topic name(0<len<%d): %s", ErrInvalidParameter, MaxTopicNameSize+1, topic)
-	case message == `` || len(message) > MaxMessageSize:
-		return nil, fmt.Errorf("%w message length(0<len<%d): %d", ErrInvalidParameter, MaxMessageSize+1, len(message))
-	case len(routingKey) > MaxRouteKeyLength:
-		return nil, fmt.Errorf("%w routing key(0<=len<%d): %s", ErrInvalidParameter, MaxRouteKeyLength+1, routingKey)
-	case len(tags) > MaxTagCount:
-		return nil, fmt.Errorf("%w message tags count[0~%d]: %v", ErrInvalidParameter, MaxTagCount, tags)
-	default:
-		if strings.Count(routingKey, `.`) > MaxRouteKeyDots {
-			return nil, fmt.Errorf("%w more than %d dot(.) in routing key: %s", ErrInvalidParameter, MaxRouteKeyDots, routingKey)
-		}
-		for _, v := range tags {
-			if v == `` || len(v) > MaxTagLength {
-				return nil, fmt.Errorf("%w message tag(0<len<%d): %s", ErrInvalidParameter, MaxTagLength+1, v)
-			}
-		}
+	return c.PublishMessageContext(context.Background(), topic, message, routingKey, tags)
+}
+
+// PublishMessageContext
+//  API: https://cloud.tencent.com/document/product/406/7411
+//  input: ctx context.Context
+//  input: topic string
+//  input: message string
+//  input: routingKey string
+//  input: tags []string
+//  return: ResponseSM
+//  return: error
+func (c *Client) PublishMessageContext(ctx context.Context, topic, message, routingKey string, tags []string) (ResponseSM, error) {
+	if err := c.limits().validatePublish(topic, message, routingKey, tags); err != nil {
+		return nil, err
 	}
 
 	values := url.Values{}
@@ -59,42 +65,63 @@ func (c *Client) PublishMessage(topic, message, routingKey string, tags []string
 	for i, t := range tags {
 		values.Set(`msgTag.`+strconv.Itoa(i), t)
 	}
-	return c.call(values)
+	return result[ResponseSM](c.call(ctx, values))
 }
 
-// BatchPublishMessage
-//  API: https://cloud.tencent.com/document/product/406/7412
+// validatePublish validate params of PublishMessage
 //  input: topic string
+//  input: message string
 //  input: routingKey string
-//  input: messages []string
 //  input: tags []string
-//  return: ResponseSMs
 //  return: error
-func (c *Client) BatchPublishMessage(topic, routingKey string, messages, tags []string) (ResponseSMs, error) {
+func (l Limits) validatePublish(topic, message, routingKey string, tags []string) error {
 	switch {
-	case !nameReg.MatchString(topic):
-		return nil, fmt.Errorf("%w topic name(0<len<%d): %s", ErrInvalidParameter, MaxTopicNameSize+1, topic)
-	case len(messages) == 0 || len(messages) > MaxMessageCount:
-		return nil, fmt.Errorf("%w messages count(0~%d]: %d", ErrInvalidParameter, MaxMessageCount, len(messages))
-	case len(routingKey) > MaxRouteKeyLength:
-		return nil, fmt.Errorf("%w routing key(0<=len<%d): %s", ErrInvalidParameter, MaxRouteKeyLength+1, routingKey)
-	case len(tags) > MaxTagCount:
-		return nil, fmt.Errorf("%w message tags count[0~%d]: %v", ErrInvalidParameter, MaxTagCount, tags)
+	case !validName(topic, l.MaxTopicNameSize):
+		return fmt.Errorf("%w topic name(0<len<%d): %s", ErrInvalidParameter, l.MaxTopicNameSize+1, topic)
+	case message == `` || len(message) > l.MaxMessageSize:
+		return fmt.Errorf("%w message length(0<len<%d): %d", ErrInvalidParameter, l.MaxMessageSize+1, len(message))
+	case len(routingKey) > l.MaxRouteKeyLength:
+		return fmt.Errorf("%w routing key(0<=len<%d): %s", ErrInvalidParameter, l.MaxRouteKeyLength+1, routingKey)
+	case len(tags) > l.MaxTagCount:
+		return fmt.Errorf("%w message tags count[0~%d]: %v", ErrInvalidParameter, l.MaxTagCount, tags)
 	default:
-		if strings.Count(routingKey, `.`) > MaxRouteKeyDots {
-			return nil, fmt.Errorf("%w more than %d dot(.) in routing key: %s", ErrInvalidParameter, MaxRouteKeyDots, routingKey)
-		}
-		for _, v := range messages {
-			if v == `` || len(v) > MaxMessageSize {
-				return nil, fmt.Errorf("%w message length(0<len<%d): %s", ErrInvalidParameter, MaxMessageSize+1, v)
-			}
+		if strings.Count(routingKey, `.`) > l.MaxRouteKeyDots {
+			return fmt.Errorf("%w more than %d dot(.) in routing key: %s", ErrInvalidParameter, l.MaxRouteKeyDots, routingKey)
 		}
 		for _, v := range tags {
-			if v == `` || len(v) > MaxTagLength {
-				return nil, fmt.Errorf("%w message tag(0<len<%d): %s", ErrInvalidParameter, MaxTagLength+1, v)
+			if v == `` || len(v) > l.MaxTagLength {
+				return fmt.Errorf("%w message tag(0<len<%d): %s", ErrInvalidParameter, l.MaxTagLength+1, v)
 			}
 		}
 	}
+	return nil
+}
+
+// BatchPublishMessage
+//  API: https://cloud.tencent.com/document/product/406/7412
+//  input: topic string
+//  input: routingKey string
+//  input: messages []string
+//  input: tags []string
+//  return: ResponseSMs
+//  return: error
+func (c *Client) BatchPublishMessage(topic, routingKey string, messages, tags []string) (ResponseSMs, error) {
+	return c.BatchPublishMessageContext(context.Background(), topic, routingKey, messages, tags)
+}
+
+// BatchPublishMessageContext
+//  API: https://cloud.tencent.com/document/product/406/7412
+//  input: ctx context.Context
+//  input: topic string
+//  input: routingKey string
+//  input: messages []string
+//  input: tags []string
+//  return: ResponseSMs
+//  return: error
+func (c *Client) BatchPublishMessageContext(ctx context.Context, topic, routingKey string, messages, tags []string) (ResponseSMs, error) {
+	if err := c.limits().validateBatchPublish(topic, routingKey, messages, tags); err != nil {
+		return nil, err
+	}
 
 	values := url.Values{}
 	values.Set(`Action`, actionBatchPub)
@@ -106,5 +133,39 @@ func (c *Client) BatchPublishMessage(topic, routingKey string, messages, tags []
 	for i, t := range tags {
 		values.Set(`msgTag.`+strconv.Itoa(i), t)
 	}
-	return c.call(values)
+	return result[ResponseSMs](c.call(ctx, values))
+}
+
+// validateBatchPublish validate params of BatchPublishMessage
+//  input: topic string
+//  input: routingKey string
+//  input: messages []string
+//  input: tags []string
+//  return: error
+func (l Limits) validateBatchPublish(topic, routingKey string, messages, tags []string) error {
+	switch {
+	case !validName(topic, l.MaxTopicNameSize):
+		return fmt.Errorf("%w topic name(0<len<%d): %s", ErrInvalidParameter, l.MaxTopicNameSize+1, topic)
+	case len(messages) == 0 || len(messages) > l.MaxMessageCount:
+		return fmt.Errorf("%w messages count(0~%d]: %d", ErrInvalidParameter, l.MaxMessageCount, len(messages))
+	case len(routingKey) > l.MaxRouteKeyLength:
+		return fmt.Errorf("%w routing key(0<=len<%d): %s", ErrInvalidParameter, l.MaxRouteKeyLength+1, routingKey)
+	case len(tags) > l.MaxTagCount:
+		return fmt.Errorf("%w message tags count[0~%d]: %v", ErrInvalidParameter, l.MaxTagCount, tags)
+	default:
+		if strings.Count(routingKey, `.`) > l.MaxRouteKeyDots {
+			return fmt.Errorf("%w more than %d dot(.) in routing key: %s", ErrInvalidParameter, l.MaxRouteKeyDots, routingKey)
+		}
+		for _, v := range messages {
+			if v == `` || len(v) > l.MaxMessageSize {
+				return fmt.Errorf("%w message length(0<len<%d): %s", ErrInvalidParameter, l.MaxMessageSize+1, v)
+			}
+		}
+		for _, v := range tags {
+			if v == `` || len(v) > l.MaxTagLength {
+				return fmt.Errorf("%w message tag(0<len<%d): %s", ErrInvalidParameter, l.MaxTagLength+1, v)
+			}
+		}
+	}
+	return nil
 }
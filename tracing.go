@@ -0,0 +1,161 @@
+package tdmq
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+type (
+	// Tracer start span for each action, adapt it to OpenTelemetry or other tracing system
+	Tracer interface {
+		Start(ctx context.Context, name string) (context.Context, Span)
+	}
+
+	// Span of an action
+	Span interface {
+		SetAttribute(key string, value any)
+		RecordError(err error)
+		End()
+	}
+)
+
+// span attribute keys
+const (
+	AttrQueue           = `cmq.queue`
+	AttrTopic           = `cmq.topic`
+	AttrMessageCount    = `cmq.message.count`
+	AttrMessageBytes    = `cmq.message.bytes`
+	AttrCode            = `cmq.code`
+	AttrRequestId       = `cmq.request_id`
+	AttrClientRequestId = `cmq.client_request_id`
+	AttrStatus          = `http.status_code`
+)
+
+// tracing interceptor start span named by action
+//  input: tracer Tracer
+//  return: Interceptor
+func tracing(tracer Tracer) Interceptor {
+	return func(ctx context.Context, action string, values url.Values, invoke Invoker) (Result, error) {
+		ctx, span := tracer.Start(ctx, action)
+		defer span.End()
+		if id, ok := ClientRequestId(ctx); ok {
+			span.SetAttribute(AttrClientRequestId, id)
+		}
+		if v := values.Get(`queueName`); v != `` {
+			span.SetAttribute(AttrQueue, v)
+		}
+		if v := values.Get(`topicName`); v != `` {
+			span.SetAttribute(AttrTopic, v)
+		}
+		var count, size int
+		for k, v := range values {
+			if k == `msgBody` || strings.HasPrefix(k, `msgBody.`) {
+				count++
+				size += len(strings.Join(v, ``))
+			}
+		}
+		if count > 0 {
+			span.SetAttribute(AttrMessageCount, count)
+			span.SetAttribute(AttrMessageBytes, size)
+		}
+
+		res, err := invoke(ctx, action, values)
+		var he *HTTPError
+		if msg, ok := res.(*msgResponse); ok && msg != nil {
+			span.SetAttribute(AttrStatus, msg.Status)
+			span.SetAttribute(AttrCode, msg.Code_)
+			span.SetAttribute(AttrRequestId, msg.RequestId_)
+			if n := len(msg.MsgInfos_); n > 0 {
+				size = 0
+				for i := range msg.MsgInfos_ {
+					size += len(msg.MsgInfos_[i].MsgBody_)
+				}
+				span.SetAttribute(AttrMessageCount, n)
+				span.SetAttribute(AttrMessageBytes, size)
+			} else if msg.MsgBody_ != `` {
+				span.SetAttribute(AttrMessageCount, 1)
+				span.SetAttribute(AttrMessageBytes, len(msg.MsgBody_))
+			}
+		} else if errors.As(err, &he) {
+			span.SetAttribute(AttrStatus, he.Status) // result is dropped on error
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+		return res, err
+	}
+}
+
+// SpanRecorder Tracer record spans in memory, for tests
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan span recorded by SpanRecorder
+type RecordedSpan struct {
+	Name       string
+	Attributes map[string]any
+	Errors     []error
+	StartTime  time.Time
+	EndTime    time.Time
+
+	mu *sync.Mutex
+}
+
+func (r *SpanRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &RecordedSpan{Name: name, Attributes: map[string]any{}, StartTime: time.Now(), mu: &r.mu}
+	r.mu.Lock()
+	r.spans = append(r.spans, s)
+	r.mu.Unlock()
+	return ctx, (*recordingSpan)(s)
+}
+
+// Spans copy of recorded spans
+//  return: []RecordedSpan
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	spans := make([]RecordedSpan, 0, len(r.spans))
+	for _, s := range r.spans {
+		cp := *s
+		cp.Attributes = make(map[string]any, len(s.Attributes))
+		for k, v := range s.Attributes {
+			cp.Attributes[k] = v
+		}
+		cp.Errors = append([]error(nil), s.Errors...)
+		spans = append(spans, cp)
+	}
+	return spans
+}
+
+// Reset drop recorded spans
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
+
+type recordingSpan RecordedSpan
+
+func (s *recordingSpan) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	s.Attributes[key] = value
+	s.mu.Unlock()
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.mu.Lock()
+	s.Errors = append(s.Errors, err)
+	s.mu.Unlock()
+}
+
+func (s *recordingSpan) End() {
+	s.mu.Lock()
+	s.EndTime = time.Now()
+	s.mu.Unlock()
+}
@@ -0,0 +1,26 @@
+package tdmq
+
+import (
+	"testing"
+	"time"
+)
+
+// TestTracingFailedRequestId failed request span has clientRequestId
+func TestTracingFailedRequestId(t *testing.T) {
+	c, err := NewClient(`http://127.0.0.1:1`, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorder := &SpanRecorder{}
+	c.Tracer = recorder
+	if _, err = c.SendMessage(`queue`, `message`, 0); err == nil {
+		t.Fatal("expect transport error")
+	}
+	spans := recorder.Spans()
+	if len(spans) != 1 || len(spans[0].Errors) != 1 {
+		t.Fatalf("unexpected spans: %+v", spans)
+	}
+	if id, ok := spans[0].Attributes[AttrClientRequestId].(uint64); !ok || id != c.id {
+		t.Fatalf("client request id %v, expect %d", spans[0].Attributes[AttrClientRequestId], c.id)
+	}
+}
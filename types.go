@@ -1,6 +1,7 @@
 package tdmq
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 )
@@ -99,7 +100,7 @@ type (
 		MsgIDs_           []msgID   `json:"msgList,omitempty"`          // 服务器生成消息的唯一标识 ID 列表，每个元素是一条消息的信息
 		MsgInfos_         []msgInfo `json:"msgInfoList,omitempty"`      // Message 信息列表，每个元素是一条消息的具体信息
 		Errors_           []msgErr  `json:"errorList,omitempty"`        // 无法成功删除的错误列表。每个元素列出了消息无法成功被删除的错误及原因
-		Raw               string    `json:"-"`
+		Raw               string    `json:"-"`                          // raw response body, kept for debug or built by String()
 	}
 
 	msgID struct {
@@ -154,7 +155,13 @@ func (m *msgResponse) Errors() (errs []MsgError) {
 	}
 	return
 }
-func (m *msgResponse) String() string { return m.Raw }
+func (m *msgResponse) String() string {
+	if m.Raw != `` {
+		return m.Raw
+	}
+	data, _ := json.Marshal(m)
+	return string(data)
+}
 
 func (m *msgID) MsgId() string             { return m.MsgId_ }
 func (m *msgInfo) MsgId() string           { return m.MsgId_ }
@@ -1,7 +1,11 @@
 package tdmq
 
-import "regexp"
+import (
+	"reflect"
+	"regexp"
+)
 
+// default limits of request parameters, used by client without Limits
 var (
 	MaxQueueNameSize  = 64
 	MaxTopicNameSize  = 64
@@ -15,13 +19,106 @@ var (
 	MaxRouteKeyDots   = 15
 	MaxTagCount       = 5
 	MaxTagLength      = 16
+	MaxResponseSize   = 32 * 1024 * 1024 // max size of response body: 32MB
+	MaxURLLength      = 8 * 1024         // max length of GET request url, many proxies reject longer url
 )
 
 var (
-	InsecureSkipVerify bool
+	InsecureSkipVerify bool // for client created by NewClient
 )
 
 var (
-	nameReg   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z\d_-]{0,63}$`)
-	handleReg = regexp.MustCompile(`^[a-zA-Z\d%#:_-]{0,256}$`)
+	nameReg   = regexp.MustCompile(`^[a-zA-Z][a-zA-Z\d_-]*$`)
+	handleReg = regexp.MustCompile(`^[a-zA-Z\d%#:_-]*$`)
 )
+
+// Limits of request parameters and response for a client
+type Limits struct {
+	MaxQueueNameSize  int
+	MaxTopicNameSize  int
+	MaxMessageSize    int
+	MaxMessageCount   int
+	MaxDelaySeconds   int
+	MaxWaitSeconds    int
+	MaxHandleCount    int
+	MaxHandleLength   int
+	MaxRouteKeyLength int
+	MaxRouteKeyDots   int
+	MaxTagCount       int
+	MaxTagLength      int
+	MaxResponseSize   int
+	MaxURLLength      int
+}
+
+// DefaultLimits current values of package level limits
+//  return: Limits
+func DefaultLimits() Limits {
+	return Limits{
+		MaxQueueNameSize:  MaxQueueNameSize,
+		MaxTopicNameSize:  MaxTopicNameSize,
+		MaxMessageSize:    MaxMessageSize,
+		MaxMessageCount:   MaxMessageCount,
+		MaxDelaySeconds:   MaxDelaySeconds,
+		MaxWaitSeconds:    MaxWaitSeconds,
+		MaxHandleCount:    MaxHandleCount,
+		MaxHandleLength:   MaxHandleLength,
+		MaxRouteKeyLength: MaxRouteKeyLength,
+		MaxRouteKeyDots:   MaxRouteKeyDots,
+		MaxTagCount:       MaxTagCount,
+		MaxTagLength:      MaxTagLength,
+		MaxResponseSize:   MaxResponseSize,
+		MaxURLLength:      MaxURLLength,
+	}
+}
+
+// withDefaults fill zero fields by package level limits
+//  return: Limits
+func (l Limits) withDefaults() Limits {
+	v, d := reflect.ValueOf(&l).Elem(), reflect.ValueOf(DefaultLimits())
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Int() == 0 {
+			v.Field(i).Set(d.Field(i))
+		}
+	}
+	return l
+}
+
+// complete check whether all limits are set
+//  return: bool
+func (l *Limits) complete() bool {
+	v := reflect.ValueOf(l).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).Int() == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// limits of client with zero fields filled by package level limits, or package level limits when not set
+//  return: Limits
+func (c *Client) limits() Limits {
+	switch {
+	case c.Limits == nil:
+		return DefaultLimits()
+	case c.Limits.complete():
+		return *c.Limits
+	}
+	return c.Limits.withDefaults()
+}
+
+// validName check queue/topic name
+//  input: name string
+//  input: max int
+//  return: bool
+func validName(name string, max int) bool {
+	return len(name) <= max && nameReg.MatchString(name)
+}
+
+// validHandle check receipt handle
+//  input: handle string
+//  input: max int
+//  return: bool
+func validHandle(handle string, max int) bool {
+	return len(handle) <= max && handleReg.MatchString(handle)
+}
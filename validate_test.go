@@ -0,0 +1,30 @@
+package tdmq
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestLimitsField zero fields of Client.Limits set directly fall back to package level limits
+func TestLimitsField(t *testing.T) {
+	srv := newTestServer(t)
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Limits = &Limits{MaxMessageSize: 16}
+	if _, err = c.SendMessage(`queue`, strings.Repeat(`m`, 16), 0); err != nil {
+		t.Fatalf("message in limit: %v", err)
+	}
+	if _, err = c.SendMessage(`queue`, strings.Repeat(`m`, 17), 0); !errors.Is(err, ErrInvalidParameter) {
+		t.Fatalf("message over limit: %v", err)
+	}
+	if _, err = c.ReceiveMessage(`queue`, 3); err != nil {
+		t.Fatalf("zero MaxWaitSeconds: %v", err)
+	}
+	if *c.Limits != (Limits{MaxMessageSize: 16}) {
+		t.Errorf("Client.Limits changed: %+v", *c.Limits)
+	}
+}
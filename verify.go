@@ -0,0 +1,124 @@
+package tdmq
+
+import (
+	"crypto/hmac"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	ErrSignatureMismatch = errors.New("signature mismatch")
+	ErrSignatureExpired  = errors.New("signature expired")
+	ErrNonceReplayed     = errors.New("nonce replayed")
+)
+
+// Verifier verify signature of request signed by Client, with timestamp window and nonce replay check
+//  presigned request of Client.Presign is accepted until its Expires and can be reused, Expires is at most MaxTTL after Timestamp.
+type Verifier struct {
+	Window time.Duration // max difference between Timestamp and local time, default: 5m
+	MaxTTL time.Duration // max lifetime from Timestamp to Expires of presigned request, default: 1h
+
+	mu     sync.Mutex
+	nonces map[string]time.Time // key: SecretId/Nonce, value: expiration
+	purged time.Time
+}
+
+var defaultVerifier = &Verifier{}
+
+// VerifySignature verify signature of request params by the default Verifier
+//  input: method string GET, POST
+//  input: host string
+//  input: path string
+//  input: values url.Values request params from query or form body
+//  input: secretKey string secret key of SecretId in values
+//  return: error
+func VerifySignature(method, host, path string, values url.Values, secretKey string) error {
+	return defaultVerifier.Verify(method, host, path, values, secretKey)
+}
+
+// Verify signature of request params, same canonicalization as Client
+//  input: method string GET, POST
+//  input: host string
+//  input: path string
+//  input: values url.Values request params from query or form body
+//  input: secretKey string secret key of SecretId in values
+//  return: error
+func (v *Verifier) Verify(method, host, path string, values url.Values, secretKey string) error {
+	sign := values.Get(`Signature`)
+	if sign == `` {
+		return fmt.Errorf("%w: no signature", ErrSignatureMismatch)
+	}
+	signMethod := values.Get(`SignatureMethod`)
+	switch signMethod {
+	case HmacSHA1, HmacSHA256:
+	case ``:
+		signMethod = HmacSHA1
+	default:
+		return fmt.Errorf("%w: unsupported sign method %s", ErrSignatureMismatch, signMethod)
+	}
+	ts, err := strconv.ParseInt(values.Get(`Timestamp`), 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp %s", ErrSignatureExpired, values.Get(`Timestamp`))
+	}
+	window := v.Window
+	if window <= 0 {
+		window = 5 * time.Minute
+	}
+	now := time.Now()
+	var expires int64 // signed by Client.Presign, reusable until expires
+	if exp := values.Get(`Expires`); exp != `` {
+		if expires, err = strconv.ParseInt(exp, 10, 64); err != nil || now.Unix() > expires {
+			return fmt.Errorf("%w: expires %s", ErrSignatureExpired, exp)
+		}
+		maxTTL := v.MaxTTL
+		if maxTTL <= 0 {
+			maxTTL = time.Hour
+		}
+		if ttl := time.Duration(expires-ts) * time.Second; ttl > maxTTL {
+			return fmt.Errorf("%w: ttl %s longer than %s", ErrSignatureExpired, ttl, maxTTL)
+		}
+	}
+	if d := now.Sub(time.Unix(ts, 0)); (d > window && expires == 0) || d < -window {
+		return fmt.Errorf("%w: timestamp %d out of window %s", ErrSignatureExpired, ts, window)
+	}
+
+	e := newEncoder(values)
+	defer e.free()
+	for i := range e.params {
+		if e.params[i].key == `Signature` {
+			e.params = append(e.params[:i], e.params[i+1:]...)
+			break
+		}
+	}
+	e.sort()
+	expected := signature(signMethod, secretKey, e.stringToSign(method, &url.URL{Host: host, Path: path}))
+	if !hmac.Equal([]byte(expected), []byte(sign)) {
+		return ErrSignatureMismatch
+	}
+
+	if nonce := values.Get(`Nonce`); nonce != `` && expires == 0 {
+		key := values.Get(`SecretId`) + `/` + nonce
+		v.mu.Lock()
+		defer v.mu.Unlock()
+		if v.nonces == nil {
+			v.nonces = map[string]time.Time{}
+		}
+		if now.Sub(v.purged) > window {
+			for k, exp := range v.nonces {
+				if now.After(exp) {
+					delete(v.nonces, k)
+				}
+			}
+			v.purged = now
+		}
+		if exp, ok := v.nonces[key]; ok && now.Before(exp) {
+			return fmt.Errorf("%w: %s", ErrNonceReplayed, nonce)
+		}
+		v.nonces[key] = time.Unix(ts, 0).Add(window)
+	}
+	return nil
+}
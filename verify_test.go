@@ -0,0 +1,67 @@
+package tdmq
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// signed capture params and host of SendMessage signed by client with secret key `secret`
+func signed(t *testing.T, clock Clock) (string, url.Values) {
+	t.Helper()
+	var host string
+	var values url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		host, values = r.Host, r.PostForm
+		_, _ = w.Write([]byte(`{"code":0}`))
+	}))
+	defer srv.Close()
+	c, err := NewClient(srv.URL, `AKIDtest`, `secret`, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.SignMethod, c.Clock = HmacSHA256, clock
+	if _, err = c.SendMessage(`queue`, `message`, 0); err != nil {
+		t.Fatal(err)
+	}
+	return host, values
+}
+
+// TestVerify signature, timestamp window and nonce replay
+func TestVerify(t *testing.T) {
+	v := &Verifier{Window: time.Minute}
+	host, values := signed(t, nil)
+	if err := v.Verify(http.MethodPost, host, `/`, values, `secret`); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if err := v.Verify(http.MethodPost, host, `/`, values, `secret`); !errors.Is(err, ErrNonceReplayed) {
+		t.Errorf("replayed: %v", err)
+	}
+
+	v = &Verifier{Window: time.Minute}
+	if err := v.Verify(http.MethodPost, host, `/`, values, `another`); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("wrong key: %v", err)
+	}
+	changed := url.Values{}
+	for k, vs := range values {
+		changed[k] = vs
+	}
+	changed.Set(`msgBody`, `changed`)
+	if err := v.Verify(http.MethodPost, host, `/`, changed, `secret`); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("changed param: %v", err)
+	}
+	if err := v.Verify(http.MethodGet, host, `/`, values, `secret`); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("changed method: %v", err)
+	}
+
+	for _, d := range []time.Duration{-2 * time.Minute, 2 * time.Minute} {
+		host, values = signed(t, fixedClock(time.Now().Add(d)))
+		if err := v.Verify(http.MethodPost, host, `/`, values, `secret`); !errors.Is(err, ErrSignatureExpired) {
+			t.Errorf("timestamp %s: %v", d, err)
+		}
+	}
+}